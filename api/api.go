@@ -1,12 +1,15 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/ketanbodas/manage-card-deck/deck/sqlitestore"
 )
 
 /*
@@ -17,10 +20,25 @@ endpoints:
 1. create new deck
 2. open deck
 3. draw cards
+4. close deck
+5. commit to a server seed of a provably fair shuffle and reveal it
+6. register, list and get custom card sets
+7. add cards to a pile, list a pile, draw from a pile and move cards between piles
+8. return cards or a pile to the deck and reshuffle the remaining cards
+9. peek at, cut and burn cards of a deck
+10. deal hands to players
+11. list the history of a deck and replay it up to an event
+12. undo and redo operations on a deck
+13. clone a deck, save, list and restore snapshots of a deck
+14. OpenAPI document of the api at /openapi.json, browsable at /docs
 */
 
 /*
 
+Errors of version 1 are frozen: every error is answered with status 400 and an
+errorMessage, except version conflicts of undo and redo with status 409, as
+clients of version 1 rely on them. Version 2 maps errors to statuses, see problem.go
+
 Some error codes:
 1 => query parameter "shuffle" has incorrect value (api: create new deck)
 2 => wrong card code provided (api: create new deck)
@@ -29,18 +47,65 @@ Some error codes:
 5 => query parameter "count" not provided (api: draw cards)
 6 => query parameter "count" has invalid value (api: draw cards)
 7 => error while drawing hand
+8 => query parameter "shuffle_method" or "shuffle_passes" has invalid value (api: create new deck)
+9 => query parameter "seed" has invalid value (api: create new deck)
+10 => error while closing deck
+11 => error while revealing server seed
+12 => query parameter "type" or "jokers" has invalid value (api: create new deck)
+13 => query parameter "decks_count" or "penetration" has invalid value (api: create new deck)
+14 => card set in request body is invalid (api: register card set)
+15 => card set not found (api: get card set)
+16 => error while adding cards to a pile
+17 => error while listing a pile
+18 => error while drawing from a pile
+19 => error while moving cards between piles
+20 => error while returning cards to the deck
+21 => error while reshuffling the deck
+22 => error while peeking at cards
+23 => error while cutting the deck
+24 => error while burning cards
+25 => error while dealing hands
+26 => error while listing or replaying the history of a deck
+27 => error while undoing or redoing operations or query parameter "version" not provided, with status 409 if the deck was changed since "version"
+28 => error while cloning a deck
+29 => error while saving, listing or restoring snapshots
 
 */
 
 // common types which are used to form rest api responses
 type deckMetadata struct {
-	Id        string `json:"deck_id"`
-	Shuffled  bool   `json:"shuffled"`
-	Remaining int    `json:"remaining"`
+	Id         string `json:"deck_id"`
+	Shuffled   bool   `json:"shuffled"`
+	Remaining  int    `json:"remaining"`
+	Seed       *int64 `json:"seed,omitempty"`
+	Commitment string `json:"commitment,omitempty"`
+	// hash of the server seed of a provably fair shuffle
+	ServerSeedHash string `json:"server_seed_hash,omitempty"`
+	Closed         bool   `json:"closed,omitempty"`
+	Type           string `json:"type,omitempty"`
+	DecksCount     int    `json:"decks_count,omitempty"`
+	CardSet        string `json:"cardset,omitempty"`
+	// incremented on every change of the deck, see undo
+	Version uint64 `json:"version"`
+}
+
+// depth of a shoe, i.e. how far it has been dealt
+type shoeDepth struct {
+	DecksCount     int     `json:"decks_count"`
+	Size           int     `json:"size"`
+	Dealt          int     `json:"dealt"`
+	RemainingDecks float64 `json:"remaining_decks"`
+	CutCard        int     `json:"cut_card,omitempty"`
+	Reshuffle      bool    `json:"reshuffle"`
+}
+
+// hash of a committed server seed, to be passed when creating a deck
+type serverSeedResponse struct {
+	ServerSeedHash string `json:"server_seed_hash"`
 }
 
 type cardsList struct {
-	Cards []deck.Card `json:"cards"`
+	Cards []cardResponse `json:"cards"`
 }
 
 // rest api responses
@@ -50,11 +115,62 @@ type newDeckResponse struct {
 
 type openDeckResponse struct {
 	deckMetadata
+	Shoe shoeDepth `json:"shoe"`
+	// number of cards drawn and not in any pile
+	Drawn int `json:"drawn"`
+	// number of cards in each pile
+	Piles map[string]int `json:"piles,omitempty"`
 	cardsList
 }
 
+type burnResponse struct {
+	deckMetadata
+	// number of cards burned, burned cards are not shown
+	Burned int `json:"burned"`
+}
+
+type hand struct {
+	Pile string `json:"pile"`
+	cardsList
+}
+
+type dealResponse struct {
+	deckMetadata
+	Hands []hand `json:"hands"`
+	// set once the cut card of the shoe is reached
+	Reshuffle bool `json:"reshuffle,omitempty"`
+}
+
+type pileResponse struct {
+	Id        string `json:"deck_id"`
+	Pile      string `json:"pile"`
+	Remaining int    `json:"remaining"`
+	cardsList
+}
+
+type historyResponse struct {
+	Id     string          `json:"deck_id"`
+	Events []eventResponse `json:"events"`
+}
+
+// summary of a snapshot, its cards are shown once it is restored
+type snapshotSummary struct {
+	Name      string         `json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	Remaining int            `json:"remaining"`
+	Drawn     int            `json:"drawn"`
+	Piles     map[string]int `json:"piles,omitempty"`
+}
+
+type snapshotsResponse struct {
+	Id        string            `json:"deck_id"`
+	Snapshots []snapshotSummary `json:"snapshots"`
+}
+
 type drawHandResponse struct {
 	cardsList
+	// set once the cut card of the shoe is reached
+	Reshuffle bool `json:"reshuffle,omitempty"`
 }
 
 // struct to return error response
@@ -63,22 +179,146 @@ type errorMessage struct {
 	Message   string `json:"error"`
 }
 
-// route apis and start server
+// handlers for the deck endpoints, backed by a deck service
+type deckHandlers struct {
+	service *deck.Service
+	// spell the diamonds suit DIAMONDS in responses, see WithCorrectSuitNames
+	correctSuitNames bool
+}
+
+// server configuration
+type Config struct {
+	// address on which server listens
+	Addr string
+	// directory in which decks are persisted, decks are kept only in memory if empty
+	DataDir string
+	// path of SQLite database in which decks are persisted, takes precedence over DataDir
+	SQLitePath string
+	// spell the diamonds suit DIAMONDS instead of the legacy DIMONDS in responses
+	CorrectSuitNames bool
+}
+
+// default configuration, listens on port 3000 and keeps decks in memory
+var DefaultConfig = Config{Addr: "localhost:3000"}
+
+// route apis and start server with decks kept in memory
 func StartServer() {
-	router := setupRouter()
-	router.Run("localhost:3000")
+	StartServerWithConfig(DefaultConfig)
 }
 
-func setupRouter() *gin.Engine {
+/*
+Route apis and start server with given configuration.
+If a SQLite database or a data directory is configured, decks persisted in it
+are available as soon as the server starts, so that decks survive restarts.
+Returns error if the store cannot be opened or the server fails
+*/
+func StartServerWithConfig(config Config) error {
+	store, e := newStore(config)
+	if e != nil {
+		return e
+	}
+	return StartServerWithService(deck.NewService(store), config)
+}
+
+/*
+Route apis and start server with decks managed by the given service. The
+store of the service is used, so DataDir and SQLitePath of the configuration
+are ignored.
+Returns error if the server fails
+*/
+func StartServerWithService(service *deck.Service, config Config) error {
+	router := setupRouter(service, routerOptions(config)...)
+	return router.Run(config.Addr)
+}
+
+// returns the options of the router selected by the configuration
+func routerOptions(config Config) []RouterOption {
+	options := []RouterOption{}
+	if config.CorrectSuitNames {
+		options = append(options, WithCorrectSuitNames())
+	}
+	return options
+}
+
+// returns the http handler of the apis with decks managed by the given service, e.g. for tests
+func NewHandler(service *deck.Service, options ...RouterOption) http.Handler {
+	return setupRouter(service, options...)
+}
+
+// returns the deck store selected by the configuration
+func newStore(config Config) (deck.DeckStore, error) {
+	if len(config.SQLitePath) > 0 {
+		return sqlitestore.Open(config.SQLitePath)
+	}
+	if len(config.DataDir) > 0 {
+		return deck.NewFileStore(config.DataDir)
+	}
+	return deck.NewMemoryStore(), nil
+}
+
+func setupRouter(service *deck.Service, options ...RouterOption) *gin.Engine {
+	h := deckHandlers{service: service}
+	for _, option := range options {
+		option(&h)
+	}
 	router := gin.Default()
-	router.POST("/deck", newDeck)
-	router.GET("/deck/open", openDeck)
-	router.GET("/deck/draw", drawCards)
+	router.POST("/deck", h.newDeck)
+	router.GET("/deck/open", h.openDeck)
+	router.GET("/deck/draw", h.drawCards)
+	router.POST("/deck/:id/close", h.closeDeck)
+	router.POST("/deck/server-seed", h.commitServerSeed)
+	router.GET("/deck/:id/reveal", h.revealSeed)
+	router.GET("/deck/:id/piles/:pile", h.listPile)
+	router.POST("/deck/:id/piles/:pile", h.addToPile)
+	router.POST("/deck/:id/piles/:pile/draw", h.drawFromPile)
+	router.POST("/deck/:id/piles/:pile/move", h.moveCards)
+	router.POST("/deck/:id/piles/:pile/return", h.returnPile)
+	router.POST("/deck/:id/return", h.returnCards)
+	router.POST("/deck/:id/reshuffle", h.reshuffle)
+	router.GET("/deck/:id/peek", h.peek)
+	router.POST("/deck/:id/cut", h.cut)
+	router.POST("/deck/:id/burn", h.burn)
+	router.POST("/deck/:id/deal", h.deal)
+	router.GET("/deck/:id/history", h.history)
+	router.GET("/deck/:id/history/:index", h.replay)
+	router.POST("/deck/:id/undo", h.undo)
+	router.POST("/deck/:id/redo", h.redo)
+	router.POST("/deck/:id/clone", h.cloneDeck)
+	router.GET("/deck/:id/snapshots", h.listSnapshots)
+	router.POST("/deck/:id/snapshots/:name", h.saveSnapshot)
+	router.POST("/deck/:id/snapshots/:name/restore", h.restoreSnapshot)
+	router.POST("/cardsets", h.registerCardSet)
+	router.GET("/cardsets", h.listCardSets)
+	router.GET("/cardsets/:id", h.getCardSet)
+	setupRouterV2(router, h)
+	setupRouterDocs(router)
 	return router
 }
 
+// returns the metadata of the deck which is part of most responses
+func newDeckMetadata(d deck.Deck) deckMetadata {
+	metadata := deckMetadata{
+		Id:        d.DeckId.String(),
+		Shuffled:  d.Shuffled,
+		Remaining: len(d.Cards),
+		Seed:      d.Seed,
+		Closed:    d.Closed,
+		Type:      d.Type,
+		CardSet:   d.CardSet,
+		Version:   d.Version,
+	}
+	if d.DecksCount > 1 {
+		metadata.DecksCount = d.DecksCount
+	}
+	if d.Proof != nil {
+		metadata.Commitment = d.Proof.Commitment
+		metadata.ServerSeedHash = d.Proof.ServerSeedHash
+	}
+	return metadata
+}
+
 // create and return new deck
-func newDeck(c *gin.Context) {
+func (h deckHandlers) newDeck(c *gin.Context) {
 	shuffleQueryParam := c.DefaultQuery("shuffle", "false")
 	shuffle, e := strconv.ParseBool(shuffleQueryParam)
 	if e != nil {
@@ -87,8 +327,86 @@ func newDeck(c *gin.Context) {
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
-	cards := c.Query("cards")
-	deck, error := deck.CreateNewDeck(shuffle, cards)
+
+	passesQueryParam := c.DefaultQuery("shuffle_passes", "0")
+	passes, e := strconv.Atoi(passesQueryParam)
+	if e != nil {
+		message := fmt.Sprintf("Invalid query param value for 'shuffle_passes': %v", passesQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 8}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	method := c.Query("shuffle_method")
+	if _, e := deck.NewShuffler(method, passes); e != nil {
+		message := fmt.Sprintf("Invalid shuffle: %v", e)
+		em := errorMessage{Message: message, ErrorCode: 8}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+
+	var seed *int64
+	if seedQueryParam, exists := c.GetQuery("seed"); exists {
+		value, e := strconv.ParseInt(seedQueryParam, 10, 64)
+		if e != nil {
+			message := fmt.Sprintf("Invalid query param value for 'seed': %v", seedQueryParam)
+			em := errorMessage{Message: message, ErrorCode: 9}
+			c.IndentedJSON(http.StatusBadRequest, em)
+			return
+		}
+		seed = &value
+	}
+
+	deckType := c.Query("type")
+	if _, e := deck.Template(deckType); e != nil {
+		message := fmt.Sprintf("Invalid query param value for 'type': %v", e)
+		em := errorMessage{Message: message, ErrorCode: 12}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	jokersQueryParam := c.DefaultQuery("jokers", "0")
+	jokers, e := strconv.Atoi(jokersQueryParam)
+	if e != nil || jokers < 0 || jokers > deck.MaxJokers {
+		message := fmt.Sprintf("Invalid query param value for 'jokers': %v, should be between 0 and %d",
+			jokersQueryParam, deck.MaxJokers)
+		em := errorMessage{Message: message, ErrorCode: 12}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+
+	decksCountQueryParam := c.DefaultQuery("decks_count", "1")
+	decksCount, e := strconv.Atoi(decksCountQueryParam)
+	if e != nil || decksCount < 1 || decksCount > deck.MaxDecksCount {
+		message := fmt.Sprintf("Invalid query param value for 'decks_count': %v, should be between 1 and %d",
+			decksCountQueryParam, deck.MaxDecksCount)
+		em := errorMessage{Message: message, ErrorCode: 13}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	penetrationQueryParam := c.DefaultQuery("penetration", "0")
+	penetration, e := strconv.ParseFloat(penetrationQueryParam, 64)
+	if e != nil || !(penetration >= 0 && penetration <= 1) {
+		message := fmt.Sprintf("Invalid query param value for 'penetration': %v, should be between 0 and 1",
+			penetrationQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 13}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+
+	options := deck.DeckOptions{
+		Shuffle:        shuffle,
+		Codes:          c.Query("cards"),
+		Type:           deckType,
+		Jokers:         jokers,
+		ShuffleMethod:  method,
+		ShufflePasses:  passes,
+		Seed:           seed,
+		ClientSeed:     c.Query("client_seed"),
+		ServerSeedHash: c.Query("server_seed_hash"),
+		DecksCount:     decksCount,
+		Penetration:    penetration,
+		CardSet:        c.Query("cardset"),
+	}
+	deck, error := h.service.CreateDeck(options)
 	if error != nil {
 		message := fmt.Sprintf("error in deck creation: %v", error)
 		em := errorMessage{Message: message, ErrorCode: 2}
@@ -96,25 +414,20 @@ func newDeck(c *gin.Context) {
 		return
 	}
 
-	metadata := deckMetadata{
-		Id:        deck.DeckId.String(),
-		Shuffled:  deck.Shuffled,
-		Remaining: len(deck.Cards),
-	}
-	response := newDeckResponse{metadata}
+	response := newDeckResponse{newDeckMetadata(deck)}
 
 	c.IndentedJSON(http.StatusOK, response)
 }
 
 // open existing deck
-func openDeck(c *gin.Context) {
+func (h deckHandlers) openDeck(c *gin.Context) {
 	deckId := c.Query("deck_id")
 	if len(deckId) == 0 {
 		em := errorMessage{Message: "'deck_id' query param not provided", ErrorCode: 3}
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
-	deck, error := deck.OpenDeck(deckId)
+	deck, error := h.service.OpenDeck(deckId)
 	if error != nil {
 		message := fmt.Sprintf("Error in opening deck: %v", error)
 		em := errorMessage{Message: message, ErrorCode: 4}
@@ -122,25 +435,42 @@ func openDeck(c *gin.Context) {
 		return
 	}
 
-	deckMetadata := deckMetadata{
-		Id:        deck.DeckId.String(),
-		Shuffled:  deck.Shuffled,
-		Remaining: len(deck.Cards),
-	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(deck))
+}
 
-	cardsList := cardsList{
-		Cards: deck.Cards,
+// returns the response of open deck, which shows all remaining cards of the deck
+func (h deckHandlers) newOpenDeckResponse(d deck.Deck) openDeckResponse {
+	response := openDeckResponse{
+		deckMetadata: newDeckMetadata(d),
+		Shoe: shoeDepth{
+			DecksCount:     d.DecksCount,
+			Size:           d.Size,
+			Dealt:          d.Dealt(),
+			RemainingDecks: d.RemainingDecks(),
+			CutCard:        d.CutCard,
+			Reshuffle:      d.NeedsReshuffle(),
+		},
+		Drawn:     len(d.Drawn),
+		cardsList: cardsList{Cards: h.cards(d.Cards)},
 	}
+	response.Piles = pileSizes(d.Piles)
+	return response
+}
 
-	response := openDeckResponse{
-		deckMetadata: deckMetadata,
-		cardsList:    cardsList,
+// returns the number of cards in each pile, nil if there are no piles
+func pileSizes(piles map[string][]deck.Card) map[string]int {
+	if len(piles) == 0 {
+		return nil
 	}
-	c.IndentedJSON(http.StatusOK, response)
+	sizes := map[string]int{}
+	for name, cards := range piles {
+		sizes[name] = len(cards)
+	}
+	return sizes
 }
 
 // draw cards from existing deck
-func drawCards(c *gin.Context) {
+func (h deckHandlers) drawCards(c *gin.Context) {
 	deckId := c.Query("deck_id")
 	if len(deckId) == 0 {
 		em := errorMessage{Message: "'deck_id' query param not provided", ErrorCode: 3}
@@ -148,38 +478,468 @@ func drawCards(c *gin.Context) {
 		return
 	}
 
+	options := deck.DrawOptions{
+		From:  c.Query("from"),
+		Codes: c.Query("code"),
+		Until: c.Query("until"),
+	}
+
+	// count is not needed when cards are drawn by code or until a card
 	count := c.Query("count")
-	if len(count) == 0 {
+	if len(count) == 0 && len(options.Codes) == 0 && len(options.Until) == 0 {
 		em := errorMessage{Message: "'count' query param not provided", ErrorCode: 5}
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
 
-	cardCount, error := strconv.ParseInt(count, 10, 32)
+	if len(count) > 0 {
+		cardCount, error := strconv.ParseInt(count, 10, 32)
+		if error != nil {
+			message := fmt.Sprintf("count '%v' is not an integer", count)
+			em := errorMessage{Message: message, ErrorCode: 6}
+			c.IndentedJSON(http.StatusBadRequest, em)
+			return
+		}
+
+		if cardCount <= 0 {
+			em := errorMessage{Message: "count must be greater than zero", ErrorCode: 6}
+			c.IndentedJSON(http.StatusBadRequest, em)
+			return
+		}
+		options.Count = int(cardCount)
+	}
+
+	hand, deck, error := h.service.Draw(deckId, options)
 	if error != nil {
-		message := fmt.Sprintf("count '%v' is not an integer", count)
-		em := errorMessage{Message: message, ErrorCode: 6}
+		message := fmt.Sprintf("Error in drawing a hand from deck: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 7}
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
 
-	if cardCount <= 0 {
-		em := errorMessage{Message: "count must be greater than zero", ErrorCode: 6}
+	cardsList := cardsList{
+		Cards: h.cards(hand),
+	}
+	response := drawHandResponse{cardsList: cardsList, Reshuffle: deck.NeedsReshuffle()}
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+// close deck, so that no more cards can be drawn and server seed can be revealed
+func (h deckHandlers) closeDeck(c *gin.Context) {
+	deck, error := h.service.CloseDeck(c.Param("id"))
+	if error != nil {
+		message := fmt.Sprintf("Error in closing deck: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 10}
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// commit to a new server seed, its hash is passed with the client seed when creating a deck
+func (h deckHandlers) commitServerSeed(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, serverSeedResponse{h.service.CommitServerSeed()})
+}
 
-	hand, error := deck.DrawCards(deckId, int(cardCount))
+// reveal server seed of an exhausted or closed deck
+func (h deckHandlers) revealSeed(c *gin.Context) {
+	proof, error := h.service.RevealSeed(c.Param("id"))
 	if error != nil {
-		message := fmt.Sprintf("Error in drawing a hand from deck: %v", error)
-		em := errorMessage{Message: message, ErrorCode: 7}
+		message := fmt.Sprintf("Error in revealing server seed: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 11}
 		c.IndentedJSON(http.StatusBadRequest, em)
 		return
 	}
+	c.IndentedJSON(http.StatusOK, proof)
+}
 
-	cardsList := cardsList{
-		Cards: hand,
+// register a custom card set given as json in the request body
+func (h deckHandlers) registerCardSet(c *gin.Context) {
+	var set deck.CardSet
+	if e := c.ShouldBindJSON(&set); e != nil {
+		message := fmt.Sprintf("Invalid card set: %v", e)
+		em := errorMessage{Message: message, ErrorCode: 14}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	set, e := deck.RegisterCardSet(set)
+	if e != nil {
+		message := fmt.Sprintf("Invalid card set: %v", e)
+		em := errorMessage{Message: message, ErrorCode: 14}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, set)
+}
+
+// list all registered card sets
+func (h deckHandlers) listCardSets(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, deck.CardSets())
+}
+
+// get a registered card set
+func (h deckHandlers) getCardSet(c *gin.Context) {
+	set, e := deck.GetCardSet(c.Param("id"))
+	if e != nil {
+		message := fmt.Sprintf("Error in getting card set: %v", e)
+		em := errorMessage{Message: message, ErrorCode: 15}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, set)
+}
+
+// returns the response with the cards of a pile
+func (h deckHandlers) newPileResponse(deckId string, pile string, cards []deck.Card) pileResponse {
+	return pileResponse{Id: deckId, Pile: pile, Remaining: len(cards), cardsList: cardsList{Cards: h.cards(cards)}}
+}
+
+// add drawn cards to the top of a pile
+func (h deckHandlers) addToPile(c *gin.Context) {
+	deckId, pile := c.Param("id"), c.Param("pile")
+	codes := c.Query("cards")
+	if len(codes) == 0 {
+		em := errorMessage{Message: "'cards' query param not provided", ErrorCode: 16}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	deck, error := h.service.AddToPile(deckId, pile, codes)
+	if error != nil {
+		message := fmt.Sprintf("Error in adding cards to pile: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 16}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newPileResponse(deckId, pile, deck.Piles[pile]))
+}
+
+// list the cards of a pile, top card first
+func (h deckHandlers) listPile(c *gin.Context) {
+	deckId, pile := c.Param("id"), c.Param("pile")
+	cards, error := h.service.Pile(deckId, pile)
+	if error != nil {
+		message := fmt.Sprintf("Error in listing pile: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 17}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newPileResponse(deckId, pile, cards))
+}
+
+// draw cards from the top, bottom or random positions of a pile
+func (h deckHandlers) drawFromPile(c *gin.Context) {
+	count := c.DefaultQuery("count", "1")
+	cardCount, error := strconv.Atoi(count)
+	if error != nil || cardCount <= 0 {
+		message := fmt.Sprintf("count '%v' is not a positive integer", count)
+		em := errorMessage{Message: message, ErrorCode: 18}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	hand, error := h.service.DrawFromPile(c.Param("id"), c.Param("pile"), cardCount, c.Query("from"))
+	if error != nil {
+		message := fmt.Sprintf("Error in drawing from pile: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 18}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, drawHandResponse{cardsList: cardsList{Cards: h.cards(hand)}})
+}
+
+// move some or all cards of a pile onto another pile
+func (h deckHandlers) moveCards(c *gin.Context) {
+	deckId := c.Param("id")
+	to := c.Query("to")
+	deck, error := h.service.MoveCards(deckId, c.Param("pile"), to, c.Query("cards"))
+	if error != nil {
+		message := fmt.Sprintf("Error in moving cards: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 19}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newPileResponse(deckId, to, deck.Piles[to]))
+}
+
+// return drawn cards to the top, bottom or random positions of the deck
+func (h deckHandlers) returnCards(c *gin.Context) {
+	codes := c.Query("cards")
+	if len(codes) == 0 {
+		em := errorMessage{Message: "'cards' query param not provided", ErrorCode: 20}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	deck, error := h.service.ReturnCards(c.Param("id"), codes, c.Query("position"))
+	if error != nil {
+		message := fmt.Sprintf("Error in returning cards: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 20}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// return all cards of a pile to the deck
+func (h deckHandlers) returnPile(c *gin.Context) {
+	deck, error := h.service.ReturnPile(c.Param("id"), c.Param("pile"), c.Query("position"))
+	if error != nil {
+		message := fmt.Sprintf("Error in returning pile: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 20}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// shuffle the cards which remain in the deck
+func (h deckHandlers) reshuffle(c *gin.Context) {
+	passesQueryParam := c.DefaultQuery("shuffle_passes", "0")
+	passes, error := strconv.Atoi(passesQueryParam)
+	if error != nil {
+		message := fmt.Sprintf("Invalid query param value for 'shuffle_passes': %v", passesQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 21}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	deck, error := h.service.ReshuffleRemaining(c.Param("id"), c.Query("shuffle_method"), passes)
+	if error != nil {
+		message := fmt.Sprintf("Error in reshuffling deck: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 21}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// look at the top cards of the deck without drawing them
+func (h deckHandlers) peek(c *gin.Context) {
+	count := c.DefaultQuery("count", "1")
+	cardCount, error := strconv.Atoi(count)
+	if error != nil || cardCount <= 0 {
+		message := fmt.Sprintf("count '%v' is not a positive integer", count)
+		em := errorMessage{Message: message, ErrorCode: 22}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	cards, error := h.service.Peek(c.Param("id"), cardCount)
+	if error != nil {
+		message := fmt.Sprintf("Error in peeking at cards: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 22}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, cardsList{Cards: h.cards(cards)})
+}
+
+// cut the deck at the given or a random position
+func (h deckHandlers) cut(c *gin.Context) {
+	position := c.DefaultQuery("position", "0")
+	cutAt, error := strconv.Atoi(position)
+	if error != nil {
+		message := fmt.Sprintf("position '%v' is not an integer", position)
+		em := errorMessage{Message: message, ErrorCode: 23}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	deck, error := h.service.Cut(c.Param("id"), cutAt)
+	if error != nil {
+		message := fmt.Sprintf("Error in cutting deck: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 23}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// burn the top cards of the deck
+func (h deckHandlers) burn(c *gin.Context) {
+	count := c.DefaultQuery("count", "1")
+	cardCount, error := strconv.Atoi(count)
+	if error != nil || cardCount <= 0 {
+		message := fmt.Sprintf("count '%v' is not a positive integer", count)
+		em := errorMessage{Message: message, ErrorCode: 24}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	burned, deck, error := h.service.Burn(c.Param("id"), cardCount)
+	if error != nil {
+		message := fmt.Sprintf("Error in burning cards: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 24}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, burnResponse{deckMetadata: newDeckMetadata(deck), Burned: len(burned)})
+}
+
+// deal hands to players, each hand is kept in a pile named after the player
+func (h deckHandlers) deal(c *gin.Context) {
+	playersQueryParam := c.Query("players")
+	players, error := strconv.Atoi(playersQueryParam)
+	if error != nil || players <= 0 {
+		message := fmt.Sprintf("players '%v' is not a positive integer", playersQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 25}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	cardsEachQueryParam := c.Query("cards_each")
+	cardsEach, error := strconv.Atoi(cardsEachQueryParam)
+	if error != nil || cardsEach <= 0 {
+		message := fmt.Sprintf("cards_each '%v' is not a positive integer", cardsEachQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 25}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+
+	hands, d, error := h.service.Deal(c.Param("id"), players, cardsEach, c.Query("order"))
+	if error != nil {
+		message := fmt.Sprintf("Error in dealing hands: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 25}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	response := dealResponse{deckMetadata: newDeckMetadata(d), Reshuffle: d.NeedsReshuffle()}
+	for i, cards := range hands {
+		response.Hands = append(response.Hands, hand{Pile: deck.PlayerPile(i + 1), cardsList: cardsList{Cards: h.cards(cards)}})
+	}
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+// list every operation on the deck, oldest first
+func (h deckHandlers) history(c *gin.Context) {
+	events, error := h.service.History(c.Param("id"))
+	if error != nil {
+		message := fmt.Sprintf("Error in listing history: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 26}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, historyResponse{Id: c.Param("id"), Events: h.events(events)})
+}
+
+// show the deck as it was right after the event at the given index of its history
+func (h deckHandlers) replay(c *gin.Context) {
+	indexParam := c.Param("index")
+	index, error := strconv.Atoi(indexParam)
+	if error != nil {
+		message := fmt.Sprintf("event index '%v' is not an integer", indexParam)
+		em := errorMessage{Message: message, ErrorCode: 26}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	deck, error := h.service.ReplayDeck(c.Param("id"), index)
+	if error != nil {
+		message := fmt.Sprintf("Error in replaying history: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 26}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(deck))
+}
+
+// revert the last operations on the deck
+func (h deckHandlers) undo(c *gin.Context) {
+	h.undoOrRedo(c, h.service.Undo, "undoing")
+}
+
+// apply undone operations on the deck again
+func (h deckHandlers) redo(c *gin.Context) {
+	h.undoOrRedo(c, h.service.Redo, "redoing")
+}
+
+/*
+Parses the "steps" and "version" query params and calls undo or redo.
+Responds with status 409 if the deck was changed since the given version
+*/
+func (h deckHandlers) undoOrRedo(c *gin.Context, apply func(string, int, uint64) (deck.Deck, error), action string) {
+	stepsQueryParam := c.DefaultQuery("steps", "1")
+	steps, error := strconv.Atoi(stepsQueryParam)
+	if error != nil || steps <= 0 {
+		message := fmt.Sprintf("steps '%v' is not a positive integer", stepsQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 27}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	versionQueryParam, exists := c.GetQuery("version")
+	if !exists {
+		em := errorMessage{Message: "query param 'version' is required, it is the version of the deck last seen", ErrorCode: 27}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	version, error := strconv.ParseUint(versionQueryParam, 10, 64)
+	if error != nil {
+		message := fmt.Sprintf("version '%v' is not a valid version", versionQueryParam)
+		em := errorMessage{Message: message, ErrorCode: 27}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+
+	d, error := apply(c.Param("id"), steps, version)
+	if error != nil {
+		message := fmt.Sprintf("Error in %v operations: %v", action, error)
+		em := errorMessage{Message: message, ErrorCode: 27}
+		status := http.StatusBadRequest
+		if errors.Is(error, deck.ErrVersionConflict) {
+			status = http.StatusConflict
+		}
+		c.IndentedJSON(status, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(d))
+}
+
+// create a new deck with the same cards, drawn cards and piles as the deck
+func (h deckHandlers) cloneDeck(c *gin.Context) {
+	deck, error := h.service.CloneDeck(c.Param("id"))
+	if error != nil {
+		message := fmt.Sprintf("Error in cloning deck: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 28}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(deck))
+}
+
+// save the current state of the deck as a named snapshot
+func (h deckHandlers) saveSnapshot(c *gin.Context) {
+	deck, error := h.service.SaveSnapshot(c.Param("id"), c.Param("name"))
+	if error != nil {
+		message := fmt.Sprintf("Error in saving snapshot: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 29}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, newDeckMetadata(deck))
+}
+
+// list the snapshots of the deck ordered by name
+func (h deckHandlers) listSnapshots(c *gin.Context) {
+	snapshots, error := h.service.Snapshots(c.Param("id"))
+	if error != nil {
+		message := fmt.Sprintf("Error in listing snapshots: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 29}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	response := snapshotsResponse{Id: c.Param("id"), Snapshots: []snapshotSummary{}}
+	for _, snapshot := range snapshots {
+		response.Snapshots = append(response.Snapshots, snapshotSummary{
+			Name:      snapshot.Name,
+			CreatedAt: snapshot.CreatedAt,
+			Remaining: len(snapshot.Cards),
+			Drawn:     len(snapshot.Drawn),
+			Piles:     pileSizes(snapshot.Piles),
+		})
 	}
-	response := drawHandResponse{cardsList}
 	c.IndentedJSON(http.StatusOK, response)
 }
+
+// restore the deck from a named snapshot
+func (h deckHandlers) restoreSnapshot(c *gin.Context) {
+	deck, error := h.service.RestoreSnapshot(c.Param("id"), c.Param("name"))
+	if error != nil {
+		message := fmt.Sprintf("Error in restoring snapshot: %v", error)
+		em := errorMessage{Message: message, ErrorCode: 29}
+		c.IndentedJSON(http.StatusBadRequest, em)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(deck))
+}
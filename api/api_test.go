@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"net/http"
@@ -9,9 +10,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/ketanbodas/manage-card-deck/deck"
 	"github.com/stretchr/testify/assert"
 )
 
+// service shared by all api tests, so that decks survive between requests
+var testService = deck.NewService(deck.NewMemoryStore())
+
 // ----------- Tests: New Deck  --------------
 
 func TestNewDeckApiFullDeckNoShuffleSuccess(t *testing.T) {
@@ -83,6 +88,116 @@ func TestNewDeckApiPartialDeckWrongCodeFailure(t *testing.T) {
 	assertBadRequestErrorCode(t, w, 2)
 }
 
+func TestNewDeckApiShuffleMethodSuccess(t *testing.T) {
+	for _, method := range []string{"fisher-yates", "riffle", "overhand", "cut", "pile"} {
+		w := runApi(http.MethodPost, "/deck?shuffle=true&shuffle_method="+method+"&shuffle_passes=2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := extractNewDeckResponse(w)
+		assert.Equal(t, 52, body.Remaining)
+		assert.Equal(t, true, body.Shuffled)
+	}
+}
+
+func TestNewDeckApiInvalidShuffleMethodFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?shuffle=true&shuffle_method=bogus")
+	assertBadRequestErrorCode(t, w, 8)
+}
+
+func TestNewDeckApiInvalidShufflePassesFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?shuffle=true&shuffle_method=riffle&shuffle_passes=x")
+	assertBadRequestErrorCode(t, w, 8)
+
+	w = runApi(http.MethodPost, "/deck?shuffle=true&shuffle_method=riffle&shuffle_passes=-2")
+	assertBadRequestErrorCode(t, w, 8)
+
+	w = runApi(http.MethodPost, "/deck?shuffle=true&shuffle_method=pile&shuffle_passes=2147483647")
+	assertBadRequestErrorCode(t, w, 8)
+}
+
+func TestNewDeckApiSeedIsReproducible(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?shuffle=true&seed=12345")
+	assert.Equal(t, http.StatusOK, w.Code)
+	first := extractNewDeckResponse(w)
+	assert.Equal(t, int64(12345), *first.Seed)
+
+	w = runApi(http.MethodPost, "/deck?shuffle=true&seed=12345")
+	second := extractNewDeckResponse(w)
+
+	firstCards := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+first.Id)).Cards
+	secondCards := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+second.Id)).Cards
+	assert.Equal(t, 52, len(firstCards))
+	assert.Equal(t, firstCards, secondCards)
+}
+
+func TestNewDeckApiInvalidSeedFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?shuffle=true&seed=abc")
+	assertBadRequestErrorCode(t, w, 9)
+}
+
+func TestNewDeckApiTemplatesSuccess(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?type=pinochle&shuffle=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := extractNewDeckResponse(w)
+	assert.Equal(t, 48, body.Remaining)
+	assert.Equal(t, "pinochle", body.Type)
+
+	w = runApi(http.MethodPost, "/deck?jokers=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	uuid := extractNewDeckResponse(w).Id
+	openDeckRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.Equal(t, 54, openDeckRes.Remaining)
+	assert.Equal(t, "X", openDeckRes.Cards[53].Code())
+
+	w = runApi(http.MethodPost, "/deck?type=piquet&cards=7S,AH")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestNewDeckApiTemplatesFailure(t *testing.T) {
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?type=tarot"), 12)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?jokers=two"), 12)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?jokers=-1"), 12)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?jokers=2000000000"), 12)
+
+	// cards and jokers must fit the template
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?type=piquet&cards=2S"), 2)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?type=euchre&jokers=1"), 2)
+}
+
+func TestShoeApi(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?decks_count=6&penetration=0.5&shuffle=true")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := extractNewDeckResponse(w)
+	assert.Equal(t, 312, body.Remaining)
+	assert.Equal(t, 6, body.DecksCount)
+	uuid := body.Id
+
+	drawRes := extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?count=155&deck_id="+uuid))
+	assert.Equal(t, 155, len(drawRes.Cards))
+	assert.False(t, drawRes.Reshuffle)
+
+	openDeckRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.Equal(t, 6, openDeckRes.Shoe.DecksCount)
+	assert.Equal(t, 312, openDeckRes.Shoe.Size)
+	assert.Equal(t, 155, openDeckRes.Shoe.Dealt)
+	assert.Equal(t, 156, openDeckRes.Shoe.CutCard)
+	assert.InDelta(t, 3.02, openDeckRes.Shoe.RemainingDecks, 0.01)
+	assert.False(t, openDeckRes.Shoe.Reshuffle)
+
+	drawRes = extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?count=1&deck_id="+uuid))
+	assert.True(t, drawRes.Reshuffle)
+	openDeckRes = extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.True(t, openDeckRes.Shoe.Reshuffle)
+}
+
+func TestShoeApiFailure(t *testing.T) {
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?decks_count=0"), 13)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?decks_count=six"), 13)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?decks_count=100"), 13)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?penetration=2"), 13)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?penetration=most"), 13)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?decks_count=6&penetration=NaN"), 13)
+}
+
 // ----------- Tests: Open Deck  --------------
 
 func TestOpenDeckApiFullDeckSuccess(t *testing.T) {
@@ -96,7 +211,7 @@ func TestOpenDeckApiFullDeckSuccess(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 52, body.Remaining)
 	assert.Equal(t, false, body.Shuffled)
-	assert.Equal(t, "AS", body.Cards[0].Code)
+	assert.Equal(t, "AS", body.Cards[0].Code())
 }
 
 func TestOpenDeckApiNoDeckIdProvidedError(t *testing.T) {
@@ -127,7 +242,7 @@ func TestOpenDeckApiPartialDeckSuccess(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 6, body.Remaining)
 	assert.Equal(t, false, body.Shuffled)
-	assert.Equal(t, "10H", body.Cards[0].Code)
+	assert.Equal(t, "10H", body.Cards[0].Code())
 }
 
 // ----------- Tests: Draw cards  --------------
@@ -142,7 +257,7 @@ func TestDrawCardsApiFullDeckSuccess(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 10, len(body.Cards))
-	assert.Equal(t, "AS", body.Cards[0].Code)
+	assert.Equal(t, "AS", body.Cards[0].Code())
 }
 
 func TestDrawCardsApiNoDeckIdProvidedError(t *testing.T) {
@@ -204,15 +319,15 @@ func TestDrawCardsApiEndToEnd(t *testing.T) {
 	body := extractDrawCardsResponse(w)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 2, len(body.Cards))
-	assert.Equal(t, "AS", body.Cards[0].Code)
-	assert.Equal(t, "KD", body.Cards[1].Code)
+	assert.Equal(t, "AS", body.Cards[0].Code())
+	assert.Equal(t, "KD", body.Cards[1].Code())
 
 	// open deck and verify 4 remaining cards
 	w = runApi(http.MethodGet, "/deck/open?deck_id="+uuid)
 	openDeckRes := extractOpenDeckResponse(w)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 4, openDeckRes.Remaining)
-	assert.Equal(t, "AC", openDeckRes.Cards[0].Code)
+	assert.Equal(t, "AC", openDeckRes.Cards[0].Code())
 
 	// draw 4 cards
 	path = "/deck/draw?deck_id=" + uuid + "&count=4"
@@ -220,10 +335,10 @@ func TestDrawCardsApiEndToEnd(t *testing.T) {
 	body = extractDrawCardsResponse(w)
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, 4, len(body.Cards))
-	assert.Equal(t, "AC", body.Cards[0].Code)
-	assert.Equal(t, "2C", body.Cards[1].Code)
-	assert.Equal(t, "KH", body.Cards[2].Code)
-	assert.Equal(t, "10H", body.Cards[3].Code)
+	assert.Equal(t, "AC", body.Cards[0].Code())
+	assert.Equal(t, "2C", body.Cards[1].Code())
+	assert.Equal(t, "KH", body.Cards[2].Code())
+	assert.Equal(t, "10H", body.Cards[3].Code())
 
 	// open deck and verify no remaining cards
 	w = runApi(http.MethodGet, "/deck/open?deck_id="+uuid)
@@ -237,17 +352,458 @@ func TestDrawCardsApiEndToEnd(t *testing.T) {
 	assertBadRequestErrorCode(t, w, 7)
 }
 
+func TestDrawCardsApiDrawModes(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=2S,KD,AC,2C,QH,10H")
+	uuid := extractNewDeckResponse(w).Id
+
+	drawRes := extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?count=1&from=bottom&deck_id="+uuid))
+	assert.Equal(t, "10H", drawRes.Cards[0].Code())
+
+	drawRes = extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?code=QH&deck_id="+uuid))
+	assert.Equal(t, "QH", drawRes.Cards[0].Code())
+
+	drawRes = extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?until=ace&deck_id="+uuid))
+	assert.Equal(t, 3, len(drawRes.Cards))
+	assert.Equal(t, "AC", drawRes.Cards[2].Code())
+
+	drawRes = extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?count=1&from=random&deck_id="+uuid))
+	assert.Equal(t, "2C", drawRes.Cards[0].Code())
+
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/draw?deck_id="+uuid), 5)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/draw?code=QH&deck_id="+uuid), 7)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/draw?count=1&from=middle&deck_id="+uuid), 7)
+}
+
+// ----------- Tests: Provably fair shuffle  --------------
+
+func TestRevealSeedApiEndToEnd(t *testing.T) {
+	// the client seed is accepted only with the hash of a committed server seed
+	w := runApi(http.MethodPost, "/deck?shuffle=true&client_seed=player1")
+	assertBadRequestErrorCode(t, w, 2)
+
+	w = runApi(http.MethodPost, "/deck/server-seed")
+	assert.Equal(t, http.StatusOK, w.Code)
+	seed := serverSeedResponse{}
+	json.Unmarshal(w.Body.Bytes(), &seed)
+	assert.Equal(t, 64, len(seed.ServerSeedHash))
+
+	w = runApi(http.MethodPost, "/deck?shuffle=true&client_seed=player1&server_seed_hash="+seed.ServerSeedHash)
+	assert.Equal(t, http.StatusOK, w.Code)
+	newDeckRes := extractNewDeckResponse(w)
+	assert.Equal(t, 64, len(newDeckRes.Commitment))
+	assert.Equal(t, seed.ServerSeedHash, newDeckRes.ServerSeedHash)
+	uuid := newDeckRes.Id
+
+	// not revealed before deck is closed
+	w = runApi(http.MethodGet, "/deck/"+uuid+"/reveal")
+	assertBadRequestErrorCode(t, w, 11)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/close")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, extractNewDeckResponse(w).Closed)
+
+	w = runApi(http.MethodGet, "/deck/"+uuid+"/reveal")
+	assert.Equal(t, http.StatusOK, w.Code)
+	proof := deck.ShuffleProof{}
+	json.Unmarshal(w.Body.Bytes(), &proof)
+	assert.Equal(t, newDeckRes.Commitment, proof.Commitment)
+	assert.Equal(t, "player1", proof.ClientSeed)
+	assert.Equal(t, seed.ServerSeedHash, deck.ServerSeedHash(proof.ServerSeed))
+
+	order, e := deck.VerifyShuffle(proof)
+	assert.Nil(t, e)
+	cards := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid)).Cards
+	assert.Equal(t, order[0], cards[0].Code())
+}
+
+func TestCloseDeckApiUnknownDeckError(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck/"+uuid.New().String()+"/close")
+	assertBadRequestErrorCode(t, w, 10)
+}
+
+// ----------- Tests: Piles  --------------
+
+func TestPilesApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD,AC,2C,KH,10H")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?count=4&deck_id="+uuid)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/piles/player1?cards=AS,KD,AC")
+	assert.Equal(t, http.StatusOK, w.Code)
+	pileRes := extractPileResponse(w)
+	assert.Equal(t, "player1", pileRes.Pile)
+	assert.Equal(t, 3, pileRes.Remaining)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard?cards=2C")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	openDeckRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.Equal(t, 0, openDeckRes.Drawn)
+	assert.Equal(t, map[string]int{"player1": 3, "discard": 1}, openDeckRes.Piles)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/piles/player1/draw?count=1&from=bottom")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "AC", extractDrawCardsResponse(w).Cards[0].Code())
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/piles/player1/move?to=discard&cards=KD")
+	assert.Equal(t, http.StatusOK, w.Code)
+	pileRes = extractPileResponse(w)
+	assert.Equal(t, "discard", pileRes.Pile)
+	assert.Equal(t, "KD", pileRes.Cards[0].Code())
+
+	pileRes = extractPileResponse(runApi(http.MethodGet, "/deck/"+uuid+"/piles/player1"))
+	assert.Equal(t, 1, pileRes.Remaining)
+	assert.Equal(t, "AS", pileRes.Cards[0].Code())
+}
+
+func TestPilesApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?count=1&deck_id="+uuid)
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard"), 16)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard?cards=KD"), 16)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/piles/discard"), 17)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard/draw"), 18)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard/draw?count=x"), 18)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard/move?to=table"), 19)
+}
+
+// ----------- Tests: Return cards  --------------
+
+func TestReturnCardsApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD,AC,2C,KH,10H")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?count=4&deck_id="+uuid)
+	runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard?cards=AC,2C")
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/return?cards=KD&position=bottom")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, extractNewDeckResponse(w).Remaining)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard/return?position=top")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, extractNewDeckResponse(w).Remaining)
+
+	openDeckRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	codes := []string{}
+	for _, card := range openDeckRes.Cards {
+		codes = append(codes, card.Code())
+	}
+	assert.Equal(t, []string{"AC", "2C", "KH", "10H", "KD"}, codes)
+	assert.Equal(t, 1, openDeckRes.Drawn)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/reshuffle?shuffle_method=riffle")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := extractNewDeckResponse(w)
+	assert.True(t, body.Shuffled)
+	assert.Equal(t, 5, body.Remaining)
+}
+
+func TestReturnCardsApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?count=1&deck_id="+uuid)
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/return"), 20)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/return?cards=KD"), 20)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/return?cards=QH"), 20)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/return?cards=AS&position=middle"), 20)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/piles/discard/return"), 20)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/reshuffle?shuffle_method=juggle"), 21)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/reshuffle?shuffle_passes=x"), 21)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/reshuffle?shuffle_method=riffle&shuffle_passes=2147483647"), 21)
+}
+
+// ----------- Tests: Peek, cut and burn  --------------
+
+func TestPeekCutBurnApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD,AC,2C,KH,10H")
+	uuid := extractNewDeckResponse(w).Id
+
+	w = runApi(http.MethodGet, "/deck/"+uuid+"/peek?count=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	peeked := extractDrawCardsResponse(w).Cards
+	assert.Equal(t, "AS", peeked[0].Code())
+	assert.Equal(t, "KD", peeked[1].Code())
+	// a peek does not change the deck, so it is not a POST
+	assert.Equal(t, http.StatusNotFound, runApi(http.MethodPost, "/deck/"+uuid+"/peek?count=2").Code)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/cut?position=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 6, extractNewDeckResponse(w).Remaining)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/burn")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, extractNewDeckResponse(w).Remaining)
+
+	pileRes := extractPileResponse(runApi(http.MethodGet, "/deck/"+uuid+"/piles/burn"))
+	assert.Equal(t, "AC", pileRes.Cards[0].Code())
+	drawRes := extractDrawCardsResponse(runApi(http.MethodGet, "/deck/draw?count=1&deck_id="+uuid))
+	assert.Equal(t, "2C", drawRes.Cards[0].Code())
+}
+
+func TestPeekCutBurnApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD")
+	uuid := extractNewDeckResponse(w).Id
+
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/peek?count=3"), 22)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/peek?count=x"), 22)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/cut?position=2"), 23)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/cut?position=x"), 23)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/burn?count=3"), 24)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/burn?count=0"), 24)
+}
+
+// ----------- Tests: Deal  --------------
+
+func TestDealApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck")
+	uuid := extractNewDeckResponse(w).Id
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=4&cards_each=5")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := dealResponse{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, 32, body.Remaining)
+	assert.Equal(t, 4, len(body.Hands))
+	assert.Equal(t, "player2", body.Hands[1].Pile)
+	assert.Equal(t, "2S", body.Hands[1].Cards[0].Code())
+	assert.Equal(t, "6S", body.Hands[1].Cards[1].Code())
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=2&cards_each=2&order=block")
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, "8D", body.Hands[0].Cards[0].Code())
+	assert.Equal(t, "9D", body.Hands[0].Cards[1].Code())
+
+	pileRes := extractPileResponse(runApi(http.MethodGet, "/deck/"+uuid+"/piles/player1"))
+	assert.Equal(t, 7, pileRes.Remaining)
+}
+
+func TestDealApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD")
+	uuid := extractNewDeckResponse(w).Id
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/deal?cards_each=1"), 25)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=2"), 25)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=2&cards_each=2"), 25)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=2&cards_each=1&order=x"), 25)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=3037000500&cards_each=3037000500"), 25)
+}
+
+// ----------- Tests: History  --------------
+
+func TestHistoryApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?cards=AS,KD,AC,2C&shuffle=true&seed=3")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?deck_id="+uuid+"&count=1&from=random")
+	runApi(http.MethodPost, "/deck/"+uuid+"/cut?position=1")
+
+	w = runApi(http.MethodGet, "/deck/"+uuid+"/history")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := historyResponse{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, uuid, body.Id)
+	assert.Equal(t, 4, len(body.Events))
+	assert.Equal(t, deck.EventShuffle, body.Events[1].Type)
+	assert.Equal(t, int64(3), *body.Events[1].Seed)
+	assert.Equal(t, 1, len(body.Events[2].Indices))
+	assert.False(t, body.Events[3].Timestamp.IsZero())
+
+	openRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	w = runApi(http.MethodGet, "/deck/"+uuid+"/history/3")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, openRes, extractOpenDeckResponse(w))
+
+	replayRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/"+uuid+"/history/0"))
+	assert.False(t, replayRes.Shuffled)
+	assert.Equal(t, 4, replayRes.Remaining)
+	assert.Equal(t, "AS", replayRes.Cards[0].Code())
+}
+
+func TestHistoryApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck")
+	uuid := extractNewDeckResponse(w).Id
+
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/not-a-uuid/history"), 26)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/history/x"), 26)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/history/1"), 26)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/"+uuid+"/history/-1"), 26)
+}
+
+func TestUndoRedoApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodGet, "/deck/draw?deck_id="+uuid+"&count=3")
+	runApi(http.MethodPost, "/deck/"+uuid+"/burn")
+	openRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.Equal(t, uint64(2), openRes.Version)
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/undo?steps=2&version=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	undoRes := extractOpenDeckResponse(w)
+	assert.Equal(t, 52, undoRes.Remaining)
+	assert.Equal(t, 0, len(undoRes.Piles))
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/redo?steps=2&version=3")
+	assert.Equal(t, http.StatusOK, w.Code)
+	redoRes := extractOpenDeckResponse(w)
+	assert.Equal(t, openRes.Cards, redoRes.Cards)
+	assert.Equal(t, 1, redoRes.Piles[deck.BurnPile])
+
+	// the deck was changed since version 2
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/undo?version=2")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 27, extractErrorResponse(w).ErrorCode)
+}
+
+func TestUndoRedoApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck")
+	uuid := extractNewDeckResponse(w).Id
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/undo?version=0"), 27)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/redo?version=0"), 27)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/undo?steps=0&version=0"), 27)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/undo?version=x"), 27)
+
+	// version is required, so that operations which were not seen are not reverted
+	runApi(http.MethodGet, "/deck/draw?deck_id="+uuid+"&count=1")
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/undo"), 27)
+	assert.Equal(t, 51, extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid)).Remaining)
+}
+
+func TestCloneAndSnapshotsApiEndToEnd(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck?shuffle=true")
+	uuid := extractNewDeckResponse(w).Id
+	runApi(http.MethodPost, "/deck/"+uuid+"/deal?players=2&cards_each=2")
+
+	w = runApi(http.MethodPost, "/deck/"+uuid+"/clone")
+	assert.Equal(t, http.StatusOK, w.Code)
+	cloneRes := extractOpenDeckResponse(w)
+	openRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.NotEqual(t, uuid, cloneRes.Id)
+	assert.Equal(t, openRes.Cards, cloneRes.Cards)
+	assert.Equal(t, map[string]int{"player1": 2, "player2": 2}, cloneRes.Piles)
+
+	w = runApi(http.MethodPost, "/deck/"+cloneRes.Id+"/snapshots/preflop")
+	assert.Equal(t, http.StatusOK, w.Code)
+	runApi(http.MethodGet, "/deck/draw?deck_id="+cloneRes.Id+"&count=5")
+
+	w = runApi(http.MethodGet, "/deck/"+cloneRes.Id+"/snapshots")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := snapshotsResponse{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, 1, len(body.Snapshots))
+	assert.Equal(t, "preflop", body.Snapshots[0].Name)
+	assert.Equal(t, 48, body.Snapshots[0].Remaining)
+
+	w = runApi(http.MethodPost, "/deck/"+cloneRes.Id+"/snapshots/preflop/restore")
+	assert.Equal(t, http.StatusOK, w.Code)
+	restoreRes := extractOpenDeckResponse(w)
+	assert.Equal(t, openRes.Cards, restoreRes.Cards)
+	assert.Equal(t, 0, restoreRes.Drawn)
+
+	// the original deck is not changed
+	openRes = extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+uuid))
+	assert.Equal(t, 48, openRes.Remaining)
+}
+
+func TestCloneAndSnapshotsApiFailure(t *testing.T) {
+	w := runApi(http.MethodPost, "/deck")
+	uuid := extractNewDeckResponse(w).Id
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/not-a-uuid/clone"), 28)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/not-a-uuid/snapshots"), 29)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/snapshots/a.b"), 29)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck/"+uuid+"/snapshots/missing/restore"), 29)
+}
+
+// ----------- Tests: Custom card sets  --------------
+
+const tarotTrumps = `{
+	"name": "tarot trumps",
+	"cards": [
+		{"code": "T0", "name": "The Fool", "attributes": {"arcana": "major", "number": 0}},
+		{"code": "T1", "name": "The Magician", "attributes": {"arcana": "major", "number": 1}},
+		{"code": "T2", "name": "The High Priestess"}
+	]
+}`
+
+func TestCardSetApiEndToEnd(t *testing.T) {
+	w := runApiWithBody(http.MethodPost, "/cardsets", tarotTrumps)
+	assert.Equal(t, http.StatusOK, w.Code)
+	set := deck.CardSet{}
+	json.Unmarshal(w.Body.Bytes(), &set)
+	assertValidUUID(t, set.Id)
+	assert.Equal(t, 3, len(set.Cards))
+
+	w = runApi(http.MethodGet, "/cardsets/"+set.Id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = runApi(http.MethodGet, "/cardsets")
+	assert.Contains(t, w.Body.String(), set.Id)
+
+	// create a deck of the set and draw its first card with attributes
+	w = runApi(http.MethodPost, "/deck?cardset="+set.Id+"&decks_count=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := extractNewDeckResponse(w)
+	assert.Equal(t, 6, body.Remaining)
+	assert.Equal(t, set.Id, body.CardSet)
+
+	w = runApi(http.MethodGet, "/deck/draw?count=1&deck_id="+body.Id)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var hand struct {
+		Cards []map[string]interface{} `json:"cards"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &hand)
+	assert.Equal(t, "T0", hand.Cards[0]["code"])
+	assert.Equal(t, "The Fool", hand.Cards[0]["value"])
+	assert.Equal(t, set.Id, hand.Cards[0]["cardset"])
+	assert.Equal(t, map[string]interface{}{"arcana": "major", "number": 0.0}, hand.Cards[0]["attributes"])
+
+	// partial deck of the set
+	w = runApi(http.MethodPost, "/deck?cardset="+set.Id+"&cards=T2,T0")
+	assert.Equal(t, http.StatusOK, w.Code)
+	openDeckRes := extractOpenDeckResponse(runApi(http.MethodGet, "/deck/open?deck_id="+extractNewDeckResponse(w).Id))
+	assert.Equal(t, "T2", openDeckRes.Cards[0].Code())
+	assert.Equal(t, "The High Priestess", openDeckRes.Cards[0].Name())
+}
+
+func TestCardSetApiFailure(t *testing.T) {
+	assertBadRequestErrorCode(t, runApiWithBody(http.MethodPost, "/cardsets", "{"), 14)
+	assertBadRequestErrorCode(t, runApiWithBody(http.MethodPost, "/cardsets", `{"name": "empty"}`), 14)
+	assertBadRequestErrorCode(t, runApiWithBody(http.MethodPost, "/cardsets",
+		`{"cards": [{"code": "A"}, {"code": "A"}]}`), 14)
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/cardsets/"+uuid.NewString()), 15)
+
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?cardset="+uuid.NewString()), 2)
+	w := runApiWithBody(http.MethodPost, "/cardsets", tarotTrumps)
+	set := deck.CardSet{}
+	json.Unmarshal(w.Body.Bytes(), &set)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?cardset="+set.Id+"&cards=AS"), 2)
+	assertBadRequestErrorCode(t, runApi(http.MethodPost, "/deck?cardset="+set.Id+"&jokers=1"), 2)
+}
+
 // ----------- Helper functions --------------
 
 func runApi(method string, path string) *httptest.ResponseRecorder {
 	gin.SetMode(gin.TestMode)
-	router := setupRouter()
+	router := setupRouter(testService)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(method, path, nil)
 	router.ServeHTTP(w, req)
 	return w
 }
 
+func runApiWithBody(method string, path string, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := setupRouter(testService)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
 func assertValidUUID(t *testing.T, uuidStr string) {
 	assert.NotNil(t, uuidStr)
 	_, e := uuid.Parse(uuidStr)
@@ -289,3 +845,44 @@ func extractDrawCardsResponse(w *httptest.ResponseRecorder) drawHandResponse {
 	json.Unmarshal([]byte(res), &body)
 	return body
 }
+
+func extractPileResponse(w *httptest.ResponseRecorder) pileResponse {
+	res := w.Body.String()
+	body := pileResponse{}
+	json.Unmarshal([]byte(res), &body)
+	return body
+}
+
+// ----------- Tests: Persistence  --------------
+
+func TestDecksReloadedFromDataDir(t *testing.T) {
+	config := Config{DataDir: t.TempDir()}
+	store, e := newStore(config)
+	assert.Nil(t, e)
+	router := setupRouter(deck.NewService(store))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/deck?cards=AS,KD", nil)
+	router.ServeHTTP(w, req)
+	uuid := extractNewDeckResponse(w).Id
+
+	// a new store on same directory, as created when server restarts
+	store, e = newStore(config)
+	assert.Nil(t, e)
+	router = setupRouter(deck.NewService(store))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/deck/open?deck_id="+uuid, nil)
+	router.ServeHTTP(w, req)
+	body := extractOpenDeckResponse(w)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, body.Remaining)
+	assert.Equal(t, "KD", body.Cards[1].Code())
+}
+
+func TestStartServerFailure(t *testing.T) {
+	// the error of a server which cannot listen is returned
+	config := Config{Addr: "localhost:-1", DataDir: t.TempDir()}
+	assert.NotNil(t, StartServerWithConfig(config))
+	assert.NotNil(t, StartServerWithService(deck.NewService(deck.NewMemoryStore()), config))
+}
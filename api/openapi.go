@@ -0,0 +1,42 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+/*
+This file serves the OpenAPI 3 document of the api and a page to browse it.
+The document is maintained by hand in openapi.json, every route of
+setupRouter must be described in it, see TestOpenAPICoversRoutes
+*/
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// page which shows openapi.json, its script and styles are inline so that it loads nothing from a third party
+//
+//go:embed docs.html
+var docsPage []byte
+
+// routes the OpenAPI document and the page to browse it
+func setupRouterDocs(router *gin.Engine) {
+	router.GET("/openapi.json", serveOpenAPI)
+	router.GET("/docs", serveDocs)
+}
+
+// return the OpenAPI document of the api
+func serveOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
+
+// policy of the docs page, which only allows its inline script and styles and requests to the server
+const docsContentSecurityPolicy = "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'"
+
+// return the page to browse the OpenAPI document
+func serveDocs(c *gin.Context) {
+	c.Header("Content-Security-Policy", docsContentSecurityPolicy)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// part of an OpenAPI document which is checked against the router
+type openAPIDocument struct {
+	OpenAPI string                            `json:"openapi"`
+	Paths   map[string]map[string]interface{} `json:"paths"`
+	// components by kind and name, e.g. schemas and parameters
+	Components map[string]map[string]interface{} `json:"components"`
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec := openAPIDocument{}
+	assert.Nil(t, json.Unmarshal(openAPISpec, &spec))
+	assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+
+	param := regexp.MustCompile(`:(\w+)`)
+	routes := map[string]bool{}
+	for _, route := range setupRouter(testService).Routes() {
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		routes[method+" "+path] = true
+		_, found := spec.Paths[path][method]
+		assert.True(t, found, "route %v %v is not described in openapi.json", route.Method, route.Path)
+	}
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			assert.True(t, routes[method+" "+path], "openapi.json describes %v %v which is not routed", method, path)
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	spec := openAPIDocument{}
+	json.Unmarshal(openAPISpec, &spec)
+	for _, name := range []string{"deckMetadata", "cardsList", "errorMessage", "problem"} {
+		assert.Contains(t, spec.Components["schemas"], name)
+	}
+	// every reference points to a defined component
+	references := regexp.MustCompile(`"#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(openAPISpec), -1)
+	assert.NotEmpty(t, references)
+	for _, reference := range references {
+		assert.Contains(t, spec.Components[reference[1]], reference[2], reference[0])
+	}
+}
+
+func TestServeOpenAPIAndDocs(t *testing.T) {
+	w := runApi(http.MethodGet, "/openapi.json")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, openAPISpec, w.Body.Bytes())
+
+	w = runApi(http.MethodGet, "/docs")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `var spec = "/openapi.json"`)
+
+	// nothing is loaded from a third party
+	assert.Equal(t, docsContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+	assert.NotRegexp(t, `(src|href)="https?:`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "//unpkg.com")
+}
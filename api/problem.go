@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+/*
+This file maps errors of package deck to HTTP statuses and RFC 7807 problem
+details, which are returned by the version 2 api. Every kind of error has a
+stable string code, clients should rely on the code and not on the detail
+*/
+
+// media type of problem details, see RFC 7807
+const problemContentType = "application/problem+json"
+
+// error of the api itself, for request bodies which cannot be decoded
+var errInvalidRequestBody = errors.New("invalid request body")
+
+// problem details of a failed request, see RFC 7807
+type problem struct {
+	// URI which identifies the kind of problem
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	// HTTP status of the response
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+	// path of the request
+	Instance string `json:"instance,omitempty"`
+	// stable machine-readable code of the kind of problem
+	Code string `json:"code"`
+}
+
+// kind of problem for errors which match err with errors.Is
+type problemType struct {
+	err    error
+	status int
+	code   string
+	title  string
+}
+
+// kinds of problems, checked in order. Unknown errors are internal errors
+var problemTypes = []problemType{
+	{errInvalidRequestBody, http.StatusBadRequest, "invalid_request_body", "Invalid request body"},
+	{deck.ErrInvalidDeckId, http.StatusBadRequest, "invalid_deck_id", "Invalid deck id"},
+	{deck.ErrDeckNotFound, http.StatusNotFound, "deck_not_found", "Deck not found"},
+	{deck.ErrPileNotFound, http.StatusNotFound, "pile_not_found", "Pile not found"},
+	{deck.ErrSnapshotNotFound, http.StatusNotFound, "snapshot_not_found", "Snapshot not found"},
+	{deck.ErrVersionConflict, http.StatusConflict, "version_conflict", "Deck was changed by another client"},
+	{deck.ErrDeckClosed, http.StatusConflict, "deck_closed", "Deck is closed"},
+	{deck.ErrInsufficientCards, http.StatusConflict, "insufficient_cards", "Not enough cards"},
+	{deck.ErrCardNotAvailable, http.StatusConflict, "card_not_available", "Card not available"},
+	{deck.ErrNothingToUndo, http.StatusConflict, "nothing_to_undo", "Nothing to undo or redo"},
+	{deck.ErrTooManySnapshots, http.StatusConflict, "too_many_snapshots", "Too many snapshots"},
+	{deck.ErrSeedNotRevealed, http.StatusConflict, "seed_not_revealed", "Server seed cannot be revealed yet"},
+	{deck.ErrInvalidHistory, http.StatusConflict, "invalid_history", "History cannot be replayed"},
+	{deck.ErrInvalidCard, http.StatusUnprocessableEntity, "invalid_card", "Invalid card"},
+	{deck.ErrInvalidCardSet, http.StatusUnprocessableEntity, "invalid_cardset", "Invalid card set"},
+	{deck.ErrCardSetNotFound, http.StatusUnprocessableEntity, "cardset_not_found", "Card set not found"},
+	{deck.ErrInvalidArgument, http.StatusUnprocessableEntity, "invalid_argument", "Invalid argument"},
+}
+
+// kind of problem for errors which match no other kind
+var internalError = problemType{nil, http.StatusInternalServerError, "internal_error", "Internal server error"}
+
+// returns the problem details of an error of the request
+func newProblem(e error, instance string) problem {
+	kind := internalError
+	for _, candidate := range problemTypes {
+		if errors.Is(e, candidate.err) {
+			kind = candidate
+			break
+		}
+	}
+	return problem{
+		Type:     "urn:manage-card-deck:problem:" + kind.code,
+		Title:    kind.title,
+		Status:   kind.status,
+		Detail:   e.Error(),
+		Instance: instance,
+		Code:     kind.code,
+	}
+}
+
+// responds with the problem details of the error and aborts the request
+func abortWithProblem(c *gin.Context, e error) {
+	p := newProblem(e, c.Request.URL.Path)
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
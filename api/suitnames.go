@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+/*
+This file contains the option which spells the diamonds suit DIAMONDS in
+responses. Cards are encoded with the legacy spelling, see
+deck.LegacyDiamondsName, so responses hold their cards as cardResponse,
+which is given the spelling of its router by deckHandlers.cards
+*/
+
+// option of the router, see NewHandler
+type RouterOption func(h *deckHandlers)
+
+// returns an option which spells the diamonds suit DIAMONDS instead of the legacy DIMONDS in responses
+func WithCorrectSuitNames() RouterOption {
+	return func(h *deckHandlers) {
+		h.correctSuitNames = true
+	}
+}
+
+// card of a response
+type cardResponse struct {
+	deck.Card
+	// spell the diamonds suit DIAMONDS instead of the legacy DIMONDS
+	correctSuitName bool
+}
+
+// fields of a card in responses, as deck.Card encodes them
+type cardFields struct {
+	Value      string                 `json:"value"`
+	Suit       string                 `json:"suit"`
+	Code       string                 `json:"code"`
+	CardSet    string                 `json:"cardset,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func (c cardResponse) MarshalJSON() ([]byte, error) {
+	if !c.correctSuitName {
+		return c.Card.MarshalJSON()
+	}
+	fields := cardFields{Value: c.Name(), Suit: c.Suit.Name(), Code: c.Code(), CardSet: c.CardSet}
+	if c.Custom != nil {
+		fields.Attributes = c.Custom.Attributes
+	}
+	return json.Marshal(fields)
+}
+
+// event of a history response, its cards are spelled as by deckHandlers.cards
+type eventResponse struct {
+	deck.Event
+	Cards []cardResponse `json:"cards,omitempty"`
+}
+
+// returns the cards of a response with the diamonds suit spelled as the router is configured
+func (h deckHandlers) cards(cards []deck.Card) []cardResponse {
+	if cards == nil {
+		return nil
+	}
+	response := make([]cardResponse, len(cards))
+	for i, card := range cards {
+		response[i] = cardResponse{Card: card, correctSuitName: h.correctSuitNames}
+	}
+	return response
+}
+
+// returns the events of a history response with their cards spelled as by cards
+func (h deckHandlers) events(events []deck.Event) []eventResponse {
+	response := make([]eventResponse, len(events))
+	for i, event := range events {
+		response[i] = eventResponse{Event: event, Cards: h.cards(event.Cards)}
+	}
+	return response
+}
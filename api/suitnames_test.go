@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrectSuitNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	corrected := setupRouter(testService, WithCorrectSuitNames())
+	legacy := setupRouter(testService)
+	serve := func(router *gin.Engine, method string, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(corrected, http.MethodPost, "/deck?cards=KD,AS,10D")
+	assert.Equal(t, http.StatusOK, w.Code)
+	uuid := extractNewDeckResponse(w).Id
+
+	w = serve(corrected, http.MethodGet, "/deck/open?deck_id="+uuid)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(w.Body.String(), `"suit": "DIAMONDS"`))
+	assert.NotContains(t, w.Body.String(), "DIMONDS")
+	assert.Equal(t, "KD", extractOpenDeckResponse(w).Cards[0].Code())
+
+	// other routers keep the legacy name
+	w = serve(legacy, http.MethodGet, "/deck/open?deck_id="+uuid)
+	assert.Equal(t, 2, strings.Count(w.Body.String(), `"suit": "DIMONDS"`))
+
+	// drawn cards and the events which took them
+	w = serve(corrected, http.MethodGet, "/deck/draw?count=1&deck_id="+uuid)
+	assert.Contains(t, w.Body.String(), `"suit": "DIAMONDS"`)
+	w = serve(corrected, http.MethodGet, "/deck/"+uuid+"/history")
+	assert.Equal(t, 3, strings.Count(w.Body.String(), `"suit": "DIAMONDS"`))
+	assert.NotContains(t, w.Body.String(), "DIMONDS")
+
+	// responses without cards are not changed
+	w = serve(corrected, http.MethodGet, "/deck/open?deck_id=x")
+	assertBadRequestErrorCode(t, w, 4)
+	w = serve(corrected, http.MethodDelete, "/v2/decks/"+uuid)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestCardResponse(t *testing.T) {
+	h := deckHandlers{correctSuitNames: true}
+	card, _ := deck.ParseCard("KD")
+	encoded, e := json.Marshal(h.cards([]deck.Card{card}))
+	assert.Nil(t, e)
+	assert.Equal(t, `[{"value":"KING","suit":"DIAMONDS","code":"KD"}]`, string(encoded))
+	legacy, _ := json.Marshal(deckHandlers{}.cards([]deck.Card{card}))
+	expected, _ := json.Marshal([]deck.Card{card})
+	assert.Equal(t, string(expected), string(legacy))
+
+	// the cards of events are spelled the same way and decoded as cards
+	encoded, _ = json.Marshal(h.events([]deck.Event{{Type: deck.EventDraw, Cards: []deck.Card{card}}}))
+	assert.Contains(t, string(encoded), `"suit":"DIAMONDS"`)
+	decoded := []cardResponse{}
+	json.Unmarshal([]byte(`[{"value":"KING","suit":"DIAMONDS","code":"KD"}]`), &decoded)
+	assert.Equal(t, card, decoded[0].Card)
+	assert.Nil(t, h.cards(nil))
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+/*
+This file contains the version 2 of the api, which addresses decks as
+resources by their path and takes request bodies as JSON
+
+endpoints:
+1. GET    /v2/decks/{id}        =>  200 with the deck
+2. POST   /v2/decks             =>  201 with the new deck
+3. POST   /v2/decks/{id}/draws  =>  200 with the drawn cards
+4. DELETE /v2/decks/{id}        =>  204
+5. GET    /v2/decks             =>  200 with a page of the decks, oldest first
+6. POST   /v2/server-seeds      =>  201 with the hash of a new server seed for a provably fair shuffle
+
+Errors are returned as problem details with a stable code, see problem.go:
+400 => request body is not valid JSON or has unknown fields, deck id is not a UUID
+404 => deck not found
+409 => request conflicts with the state of the deck, e.g. deck is closed or has fewer cards
+422 => request is well-formed but has invalid values, e.g. an unknown card code
+*/
+
+// request body to create a deck, see deck.DeckOptions
+type createDeckRequest struct {
+	Shuffle       bool     `json:"shuffle"`
+	Cards         []string `json:"cards"`
+	Type          string   `json:"type"`
+	Jokers        int      `json:"jokers"`
+	ShuffleMethod string   `json:"shuffle_method"`
+	ShufflePasses int      `json:"shuffle_passes"`
+	Seed          *int64   `json:"seed"`
+	ClientSeed    string   `json:"client_seed"`
+	// hash from POST /v2/server-seeds, required with client_seed
+	ServerSeedHash string  `json:"server_seed_hash"`
+	DecksCount     int     `json:"decks_count"`
+	Penetration    float64 `json:"penetration"`
+	CardSet        string  `json:"cardset"`
+}
+
+// request body to draw cards, see deck.DrawOptions
+type drawRequest struct {
+	Count int      `json:"count"`
+	From  string   `json:"from"`
+	Cards []string `json:"cards"`
+	Until string   `json:"until"`
+}
+
+// deck as listed, without its cards
+type deckSummary struct {
+	deckMetadata
+	// number of cards drawn and not in any pile
+	Drawn int `json:"drawn"`
+}
+
+type decksResponse struct {
+	Decks []deckSummary `json:"decks"`
+	// cursor of the next page, not set for the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type drawResponse struct {
+	deckMetadata
+	cardsList
+	// set once the cut card of the shoe is reached
+	Reshuffle bool `json:"reshuffle,omitempty"`
+}
+
+// routes the version 2 api, which is served next to version 1
+func setupRouterV2(router *gin.Engine, h deckHandlers) {
+	v2 := router.Group("/v2")
+	v2.GET("/decks", h.listDecksV2)
+	v2.POST("/decks", h.createDeckV2)
+	v2.GET("/decks/:id", h.getDeckV2)
+	v2.DELETE("/decks/:id", h.deleteDeckV2)
+	v2.POST("/decks/:id/draws", h.drawV2)
+	v2.POST("/server-seeds", h.commitServerSeedV2)
+}
+
+// create a deck from the options in the request body
+func (h deckHandlers) createDeckV2(c *gin.Context) {
+	var request createDeckRequest
+	if !bindStrictJSON(c, &request) {
+		return
+	}
+	options := deck.DeckOptions{
+		Shuffle:        request.Shuffle,
+		Codes:          strings.Join(request.Cards, ","),
+		Type:           request.Type,
+		Jokers:         request.Jokers,
+		ShuffleMethod:  request.ShuffleMethod,
+		ShufflePasses:  request.ShufflePasses,
+		Seed:           request.Seed,
+		ClientSeed:     request.ClientSeed,
+		ServerSeedHash: request.ServerSeedHash,
+		DecksCount:     request.DecksCount,
+		Penetration:    request.Penetration,
+		CardSet:        request.CardSet,
+	}
+	if _, e := deck.NewShuffler(options.ShuffleMethod, options.ShufflePasses); e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	d, e := h.service.CreateDeck(options)
+	if e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	c.Header("Location", "/v2/decks/"+d.DeckId.String())
+	c.IndentedJSON(http.StatusCreated, h.newOpenDeckResponse(d))
+}
+
+// commit to a new server seed which a deck with a client seed is shuffled with
+func (h deckHandlers) commitServerSeedV2(c *gin.Context) {
+	c.IndentedJSON(http.StatusCreated, serverSeedResponse{h.service.CommitServerSeed()})
+}
+
+// list a page of the decks without their cards, oldest first
+func (h deckHandlers) listDecksV2(c *gin.Context) {
+	limit := deck.MaxDecksPage
+	if value, exists := c.GetQuery("limit"); exists {
+		var e error
+		if limit, e = strconv.Atoi(value); e != nil {
+			abortWithProblem(c, fmt.Errorf("%w: limit '%v' is not a number", deck.ErrInvalidArgument, value))
+			return
+		}
+	}
+	decks, cursor, e := h.service.ListDecksPage(limit, c.Query("cursor"))
+	if e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	response := decksResponse{Decks: []deckSummary{}, NextCursor: cursor}
+	for _, d := range decks {
+		response.Decks = append(response.Decks, deckSummary{deckMetadata: newDeckMetadata(d), Drawn: len(d.Drawn)})
+	}
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+// return the deck with all its remaining cards
+func (h deckHandlers) getDeckV2(c *gin.Context) {
+	d, e := h.service.OpenDeck(c.Param("id"))
+	if e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, h.newOpenDeckResponse(d))
+}
+
+// delete the deck
+func (h deckHandlers) deleteDeckV2(c *gin.Context) {
+	if e := h.service.DeleteDeck(c.Param("id")); e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// draw cards as given in the request body
+func (h deckHandlers) drawV2(c *gin.Context) {
+	var request drawRequest
+	if !bindStrictJSON(c, &request) {
+		return
+	}
+	options := deck.DrawOptions{
+		Count: request.Count,
+		From:  request.From,
+		Codes: strings.Join(request.Cards, ","),
+		Until: request.Until,
+	}
+	hand, d, e := h.service.Draw(c.Param("id"), options)
+	if e != nil {
+		abortWithProblem(c, e)
+		return
+	}
+	response := drawResponse{
+		deckMetadata: newDeckMetadata(d),
+		cardsList:    cardsList{Cards: h.cards(hand)},
+		Reshuffle:    d.NeedsReshuffle(),
+	}
+	c.IndentedJSON(http.StatusOK, response)
+}
+
+/*
+Decodes the JSON request body into request, an empty body leaves request
+unchanged. Unknown fields are rejected so that misspelled options are not
+silently ignored. Responds with a problem and returns false if the body cannot be decoded
+*/
+func bindStrictJSON(c *gin.Context, request interface{}) bool {
+	if c.Request.Body == nil {
+		return true
+	}
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if e := decoder.Decode(request); e != nil && !errors.Is(e, io.EOF) {
+		abortWithProblem(c, fmt.Errorf("%w: %v", errInvalidRequestBody, e))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDeckV2(t *testing.T) {
+	w := runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": ["AS", "KD", "AC"], "shuffle": true, "seed": 7}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	body := extractOpenDeckResponse(w)
+	assertValidUUID(t, body.Id)
+	assert.Equal(t, "/v2/decks/"+body.Id, w.Header().Get("Location"))
+	assert.True(t, body.Shuffled)
+	assert.Equal(t, 3, body.Remaining)
+	assert.Equal(t, int64(7), *body.Seed)
+
+	w = runApi(http.MethodPost, "/v2/decks")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 52, extractOpenDeckResponse(w).Remaining)
+
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": "AS"}`), http.StatusBadRequest, "invalid_request_body")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"shufle": true}`), http.StatusBadRequest, "invalid_request_body")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": ["ZZ"]}`), http.StatusUnprocessableEntity, "invalid_card")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"shuffle_method": "x"}`), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"decks_count": 17}`), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"jokers": 2000000000}`), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"cardset": "unknown"}`), http.StatusUnprocessableEntity, "cardset_not_found")
+}
+
+func TestCommitServerSeedV2(t *testing.T) {
+	w := runApi(http.MethodPost, "/v2/server-seeds")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	seed := serverSeedResponse{}
+	json.Unmarshal(w.Body.Bytes(), &seed)
+	assert.Equal(t, 64, len(seed.ServerSeedHash))
+
+	request := `{"shuffle": true, "client_seed": "player1", "server_seed_hash": "` + seed.ServerSeedHash + `"}`
+	w = runApiWithBody(http.MethodPost, "/v2/decks", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, seed.ServerSeedHash, extractOpenDeckResponse(w).ServerSeedHash)
+
+	// a committed server seed shuffles a single deck
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", request), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks", `{"shuffle": true, "client_seed": "player1"}`),
+		http.StatusUnprocessableEntity, "invalid_argument")
+}
+
+func TestGetAndDeleteDeckV2(t *testing.T) {
+	w := runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": ["AS", "KD"]}`)
+	uuid := extractOpenDeckResponse(w).Id
+
+	w = runApi(http.MethodGet, "/v2/decks/"+uuid)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := extractOpenDeckResponse(w)
+	assert.Equal(t, uuid, body.Id)
+	assert.Equal(t, "AS", body.Cards[0].Code())
+
+	w = runApi(http.MethodDelete, "/v2/decks/"+uuid)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertProblem(t, runApi(http.MethodGet, "/v2/decks/"+uuid), http.StatusNotFound, "deck_not_found")
+	assertProblem(t, runApi(http.MethodDelete, "/v2/decks/"+uuid), http.StatusNotFound, "deck_not_found")
+	assertProblem(t, runApi(http.MethodGet, "/v2/decks/not-a-uuid"), http.StatusBadRequest, "invalid_deck_id")
+
+	// the deck is gone for version 1 too
+	assertBadRequestErrorCode(t, runApi(http.MethodGet, "/deck/open?deck_id="+uuid), 4)
+}
+
+func TestListDecksV2(t *testing.T) {
+	w := runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": ["AS", "KD"]}`)
+	uuid := extractOpenDeckResponse(w).Id
+
+	w = runApi(http.MethodGet, "/v2/decks")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := decksResponse{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	last := body.Decks[len(body.Decks)-1]
+	assert.Equal(t, uuid, last.Id)
+	assert.Equal(t, 2, last.Remaining)
+	assert.Equal(t, 0, last.Drawn)
+
+	runApi(http.MethodDelete, "/v2/decks/"+uuid)
+	w = runApi(http.MethodGet, "/v2/decks")
+	json.Unmarshal(w.Body.Bytes(), &body)
+	for _, d := range body.Decks {
+		assert.NotEqual(t, uuid, d.Id)
+	}
+}
+
+func TestListDecksPagesV2(t *testing.T) {
+	router := setupRouter(deck.NewService(deck.NewMemoryStore()))
+	list := func(query string) decksResponse {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v2/decks"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := decksResponse{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body
+	}
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/v2/decks", nil)
+		router.ServeHTTP(w, req)
+		ids = append(ids, extractOpenDeckResponse(w).Id)
+	}
+
+	page := list("?limit=2")
+	assert.Equal(t, ids[:2], []string{page.Decks[0].Id, page.Decks[1].Id})
+	assert.NotEmpty(t, page.NextCursor)
+	page = list("?limit=2&cursor=" + page.NextCursor)
+	assert.Equal(t, 1, len(page.Decks))
+	assert.Equal(t, ids[2], page.Decks[0].Id)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, 3, len(list("").Decks))
+
+	assertProblem(t, runApi(http.MethodGet, "/v2/decks?limit=x"), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApi(http.MethodGet, "/v2/decks?limit=0"), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApi(http.MethodGet, "/v2/decks?cursor=x"), http.StatusUnprocessableEntity, "invalid_argument")
+}
+
+func TestDrawV2(t *testing.T) {
+	w := runApiWithBody(http.MethodPost, "/v2/decks", `{"cards": ["AS", "KD", "AC", "2C"]}`)
+	uuid := extractOpenDeckResponse(w).Id
+
+	w = runApiWithBody(http.MethodPost, "/v2/decks/"+uuid+"/draws", `{"count": 2, "from": "bottom"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := drawResponse{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, 2, len(body.Cards))
+	assert.Equal(t, "2C", body.Cards[0].Code())
+	assert.Equal(t, 2, body.Remaining)
+
+	w = runApiWithBody(http.MethodPost, "/v2/decks/"+uuid+"/draws", `{"cards": ["KD"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	draws := "/v2/decks/" + uuid + "/draws"
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{"count": 2}`), http.StatusConflict, "insufficient_cards")
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{"cards": ["KD"]}`), http.StatusConflict, "card_not_available")
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{"count": 0}`), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{"count": 1, "from": "middle"}`), http.StatusUnprocessableEntity, "invalid_argument")
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{count: 1}`), http.StatusBadRequest, "invalid_request_body")
+	assertProblem(t, runApiWithBody(http.MethodPost, "/v2/decks/4c0c167a-5ba6-4437-a09d-9dcb7748df43/draws", `{"count": 1}`),
+		http.StatusNotFound, "deck_not_found")
+
+	runApi(http.MethodPost, "/deck/"+uuid+"/close")
+	assertProblem(t, runApiWithBody(http.MethodPost, draws, `{"count": 1}`), http.StatusConflict, "deck_closed")
+}
+
+func TestProblemDetails(t *testing.T) {
+	w := runApi(http.MethodGet, "/v2/decks/4c0c167a-5ba6-4437-a09d-9dcb7748df43")
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	body := problem{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, "urn:manage-card-deck:problem:deck_not_found", body.Type)
+	assert.Equal(t, "Deck not found", body.Title)
+	assert.Equal(t, http.StatusNotFound, body.Status)
+	assert.Equal(t, "deck not found for the input uuid 4c0c167a-5ba6-4437-a09d-9dcb7748df43", body.Detail)
+	assert.Equal(t, "/v2/decks/4c0c167a-5ba6-4437-a09d-9dcb7748df43", body.Instance)
+
+	// errors which match no kind are internal errors
+	p := newProblem(errors.New("disk full"), "")
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+	assert.Equal(t, "internal_error", p.Code)
+
+	// codes are unique
+	codes := map[string]bool{}
+	for _, kind := range problemTypes {
+		assert.False(t, codes[kind.code], kind.code)
+		codes[kind.code] = true
+	}
+}
+
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	assert.Equal(t, status, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	body := problem{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, status, body.Status)
+	assert.Equal(t, code, body.Code)
+	assert.NotEmpty(t, body.Detail)
+}
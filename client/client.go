@@ -0,0 +1,400 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+/*
+This file contains a client of the deck api, so that programs can create,
+open, draw from, list and delete decks of a server without writing their own
+http calls. The client calls version 2 of the api. Cards are returned as
+deck.Card, errors of the api as *Error
+*/
+
+// client of a deck server
+type Client struct {
+	// URL of the server, e.g. http://localhost:3000
+	BaseURL string
+	// client which sends the requests
+	HTTPClient *http.Client
+	// number of times a failed request is retried, see retryable
+	MaxRetries int
+	// delay before the first retry, doubled for every further retry
+	RetryDelay time.Duration
+}
+
+// deck as returned by the server, cards are only set by OpenDeck
+type Deck struct {
+	Id             string `json:"deck_id"`
+	Shuffled       bool   `json:"shuffled"`
+	Remaining      int    `json:"remaining"`
+	Seed           *int64 `json:"seed,omitempty"`
+	Commitment     string `json:"commitment,omitempty"`
+	ServerSeedHash string `json:"server_seed_hash,omitempty"`
+	Closed         bool   `json:"closed,omitempty"`
+	Type           string `json:"type,omitempty"`
+	DecksCount     int    `json:"decks_count,omitempty"`
+	CardSet        string `json:"cardset,omitempty"`
+	Version        uint64 `json:"version"`
+	// number of cards drawn and not in any pile
+	Drawn int `json:"drawn"`
+	// number of cards in each pile
+	Piles map[string]int `json:"piles,omitempty"`
+	Cards []deck.Card    `json:"cards,omitempty"`
+}
+
+// cards drawn from a deck
+type DrawResult struct {
+	Cards []deck.Card `json:"cards"`
+	// set once the cut card of the shoe is reached
+	Reshuffle bool `json:"reshuffle,omitempty"`
+}
+
+// request body to create a deck, see deck.DeckOptions
+type createDeckRequest struct {
+	Shuffle        bool     `json:"shuffle,omitempty"`
+	Cards          []string `json:"cards,omitempty"`
+	Type           string   `json:"type,omitempty"`
+	Jokers         int      `json:"jokers,omitempty"`
+	ShuffleMethod  string   `json:"shuffle_method,omitempty"`
+	ShufflePasses  int      `json:"shuffle_passes,omitempty"`
+	Seed           *int64   `json:"seed,omitempty"`
+	ClientSeed     string   `json:"client_seed,omitempty"`
+	ServerSeedHash string   `json:"server_seed_hash,omitempty"`
+	DecksCount     int      `json:"decks_count,omitempty"`
+	Penetration    float64  `json:"penetration,omitempty"`
+	CardSet        string   `json:"cardset,omitempty"`
+}
+
+// request body to draw cards, see deck.DrawOptions
+type drawRequest struct {
+	Count int      `json:"count,omitempty"`
+	From  string   `json:"from,omitempty"`
+	Cards []string `json:"cards,omitempty"`
+	Until string   `json:"until,omitempty"`
+}
+
+/*
+Error response of the api, see the list of error codes in the README.
+Use errors.As to get it from an error of the client. The error matches the
+kind of error of package deck with errors.Is, e.g. deck.ErrDeckNotFound
+*/
+type Error struct {
+	// HTTP status of the response
+	StatusCode int
+	// error code of a version 1 response
+	Code int
+	// code of the problem details of a version 2 response, e.g. deck_not_found
+	Problem string
+	Message string
+}
+
+// kinds of errors of package deck by the code of their problem details
+var problemErrors = map[string]error{
+	"invalid_deck_id":    deck.ErrInvalidDeckId,
+	"deck_not_found":     deck.ErrDeckNotFound,
+	"pile_not_found":     deck.ErrPileNotFound,
+	"snapshot_not_found": deck.ErrSnapshotNotFound,
+	"version_conflict":   deck.ErrVersionConflict,
+	"deck_closed":        deck.ErrDeckClosed,
+	"insufficient_cards": deck.ErrInsufficientCards,
+	"card_not_available": deck.ErrCardNotAvailable,
+	"nothing_to_undo":    deck.ErrNothingToUndo,
+	"too_many_snapshots": deck.ErrTooManySnapshots,
+	"seed_not_revealed":  deck.ErrSeedNotRevealed,
+	"invalid_history":    deck.ErrInvalidHistory,
+	"invalid_card":       deck.ErrInvalidCard,
+	"invalid_cardset":    deck.ErrInvalidCardSet,
+	"cardset_not_found":  deck.ErrCardSetNotFound,
+	"invalid_argument":   deck.ErrInvalidArgument,
+}
+
+func (e *Error) Error() string {
+	if len(e.Problem) > 0 {
+		return fmt.Sprintf("deck api error %v (status %v): %v", e.Problem, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("deck api error %v (status %v): %v", e.Code, e.StatusCode, e.Message)
+}
+
+// returns the kind of error of package deck given by the problem code, nil if there is none
+func (e *Error) Unwrap() error {
+	return problemErrors[e.Problem]
+}
+
+// returns a client of the server at baseURL which retries failed requests twice
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		MaxRetries: 2,
+		RetryDelay: 100 * time.Millisecond,
+	}
+}
+
+/*
+Creates a new deck
+inputs:
+	ctx     :  context of the request
+	options :  options of the deck, see deck.DeckOptions
+returns:
+	the new deck without its cards
+	error if the request fails or the server rejects the options
+*/
+func (c *Client) CreateDeck(ctx context.Context, options deck.DeckOptions) (Deck, error) {
+	request := createDeckRequest{
+		Shuffle:        options.Shuffle,
+		Cards:          splitCodes(options.Codes),
+		Type:           options.Type,
+		Jokers:         options.Jokers,
+		ShuffleMethod:  options.ShuffleMethod,
+		ShufflePasses:  options.ShufflePasses,
+		Seed:           options.Seed,
+		ClientSeed:     options.ClientSeed,
+		ServerSeedHash: options.ServerSeedHash,
+		DecksCount:     options.DecksCount,
+		Penetration:    options.Penetration,
+		CardSet:        options.CardSet,
+	}
+	var d Deck
+	e := c.do(ctx, http.MethodPost, "/v2/decks", request, false, &d)
+	// the server returns the cards of the new deck, they are shown by OpenDeck only
+	d.Cards = nil
+	return d, e
+}
+
+/*
+Commits the server to a new seed for a provably fair shuffle. Pass the hash
+as ServerSeedHash together with the client seed to CreateDeck
+inputs:
+	ctx :  context of the request
+returns:
+	hash of the committed server seed
+	error if the request fails
+*/
+func (c *Client) CommitServerSeed(ctx context.Context) (string, error) {
+	var response struct {
+		ServerSeedHash string `json:"server_seed_hash"`
+	}
+	e := c.do(ctx, http.MethodPost, "/v2/server-seeds", nil, false, &response)
+	return response.ServerSeedHash, e
+}
+
+/*
+Opens a deck
+inputs:
+	ctx    :  context of the request
+	deckId :  a UUID in string format
+returns:
+	the deck with all its remaining cards
+	error if the request fails or the deck is not found
+*/
+func (c *Client) OpenDeck(ctx context.Context, deckId string) (Deck, error) {
+	var d Deck
+	e := c.do(ctx, http.MethodGet, deckPath(deckId), nil, true, &d)
+	return d, e
+}
+
+/*
+Draws cards from a deck
+inputs:
+	ctx     :  context of the request
+	deckId  :  a UUID in string format
+	options :  cards to draw, see deck.DrawOptions
+returns:
+	the drawn cards
+	error if the request fails or the cards cannot be drawn
+*/
+func (c *Client) Draw(ctx context.Context, deckId string, options deck.DrawOptions) (DrawResult, error) {
+	request := drawRequest{
+		Count: options.Count,
+		From:  options.From,
+		Cards: splitCodes(options.Codes),
+		Until: options.Until,
+	}
+	var result DrawResult
+	e := c.do(ctx, http.MethodPost, deckPath(deckId)+"/draws", request, false, &result)
+	return result, e
+}
+
+/*
+Lists all decks, oldest first, requesting one page after the other
+inputs:
+	ctx :  context of the request
+returns:
+	the decks without their cards
+	error if a request fails
+*/
+func (c *Client) ListDecks(ctx context.Context) ([]Deck, error) {
+	decks := []Deck{}
+	cursor := ""
+	for {
+		page, next, e := c.ListDecksPage(ctx, deck.MaxDecksPage, cursor)
+		if e != nil {
+			return nil, e
+		}
+		decks = append(decks, page...)
+		if len(next) == 0 {
+			return decks, nil
+		}
+		cursor = next
+	}
+}
+
+/*
+Lists a page of the decks, oldest first
+inputs:
+	ctx    :  context of the request
+	limit  :  maximum number of decks, from 1 to deck.MaxDecksPage
+	cursor :  cursor returned with the previous page, empty for the first page
+returns:
+	the decks without their cards
+	cursor of the next page, empty if there are no more decks
+	error if the request fails or limit or cursor is invalid
+*/
+func (c *Client) ListDecksPage(ctx context.Context, limit int, cursor string) ([]Deck, string, error) {
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if len(cursor) > 0 {
+		query.Set("cursor", cursor)
+	}
+	var response struct {
+		Decks      []Deck `json:"decks"`
+		NextCursor string `json:"next_cursor"`
+	}
+	e := c.do(ctx, http.MethodGet, "/v2/decks?"+query.Encode(), nil, true, &response)
+	return response.Decks, response.NextCursor, e
+}
+
+/*
+Deletes a deck
+inputs:
+	ctx    :  context of the request
+	deckId :  a UUID in string format
+returns:
+	error if the request fails or the deck is not found
+*/
+func (c *Client) DeleteDeck(ctx context.Context, deckId string) error {
+	return c.do(ctx, http.MethodDelete, deckPath(deckId), nil, true, nil)
+}
+
+/*
+Sends a request with body as JSON unless body is nil and decodes the JSON
+response into out unless out is nil, retrying failed requests as given by
+retryable. Requests which are not idempotent are only retried if the server
+did not process them
+*/
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, idempotent bool, out interface{}) error {
+	var encoded []byte
+	if body != nil {
+		var e error
+		if encoded, e = json.Marshal(body); e != nil {
+			return fmt.Errorf("invalid request of %v %v: %w", method, path, e)
+		}
+	}
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		response, e := c.send(ctx, method, path, encoded)
+		if attempt < c.MaxRetries && retryable(response, e, idempotent) && ctx.Err() == nil {
+			if response != nil {
+				response.Body.Close()
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+			continue
+		}
+		if e != nil {
+			return e
+		}
+		defer response.Body.Close()
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return decodeError(response)
+		}
+		if out == nil {
+			return nil
+		}
+		if e := json.NewDecoder(response.Body).Decode(out); e != nil {
+			return fmt.Errorf("invalid response of %v %v: %w", method, path, e)
+		}
+		return nil
+	}
+}
+
+// sends a single request with the JSON body unless it is nil
+func (c *Client) send(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	request, e := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+	if e != nil {
+		return nil, e
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(request)
+}
+
+// returns the error of a failed response, either an error message or problem details
+func decodeError(response *http.Response) *Error {
+	var body struct {
+		ErrorCode int    `json:"errorCode"`
+		Error     string `json:"error"`
+		Code      string `json:"code"`
+		Detail    string `json:"detail"`
+	}
+	apiError := &Error{StatusCode: response.StatusCode, Message: http.StatusText(response.StatusCode)}
+	if e := json.NewDecoder(response.Body).Decode(&body); e != nil {
+		return apiError
+	}
+	apiError.Code, apiError.Problem = body.ErrorCode, body.Code
+	if len(body.Error) > 0 {
+		apiError.Message = body.Error
+	} else if len(body.Detail) > 0 {
+		apiError.Message = body.Detail
+	}
+	return apiError
+}
+
+/*
+Tells whether a request is retried. Any request is retried if the server is
+overloaded or unavailable, i.e. status 429 or 503. Idempotent requests are
+also retried on other server errors and if no response was received
+*/
+func retryable(response *http.Response, e error, idempotent bool) bool {
+	if e != nil {
+		return idempotent
+	}
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	return idempotent && response.StatusCode >= http.StatusInternalServerError
+}
+
+// returns the path of a deck in version 2 of the api
+func deckPath(deckId string) string {
+	return "/v2/decks/" + url.PathEscape(deckId)
+}
+
+// returns the codes of a comma separated list, nil if it is empty
+func splitCodes(codes string) []string {
+	if len(codes) == 0 {
+		return nil
+	}
+	return strings.Split(codes, ",")
+}
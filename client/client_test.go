@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ketanbodas/manage-card-deck/api"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+// returns a client of a test server running the deck api
+func newTestClient(t *testing.T) *Client {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewServer(api.NewHandler(deck.NewService(deck.NewMemoryStore())))
+	t.Cleanup(server.Close)
+	return New(server.URL)
+}
+
+func TestCreateOpenAndDraw(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	seed := int64(7)
+	d, e := client.CreateDeck(ctx, deck.DeckOptions{Codes: "AS,KD,AC,2C", Shuffle: true, Seed: &seed})
+	assert.Nil(t, e)
+	assert.Equal(t, 4, d.Remaining)
+	assert.True(t, d.Shuffled)
+	assert.Equal(t, seed, *d.Seed)
+	assert.Empty(t, d.Cards)
+
+	opened, e := client.OpenDeck(ctx, d.Id)
+	assert.Nil(t, e)
+	assert.Equal(t, d.Id, opened.Id)
+	assert.Equal(t, 4, len(opened.Cards))
+
+	drawn, e := client.Draw(ctx, d.Id, deck.DrawOptions{Count: 2, From: "bottom"})
+	assert.Nil(t, e)
+	assert.Equal(t, opened.Cards[2:], []deck.Card{drawn.Cards[1], drawn.Cards[0]})
+
+	drawn, e = client.Draw(ctx, d.Id, deck.DrawOptions{Codes: opened.Cards[0].Code()})
+	assert.Nil(t, e)
+	assert.Equal(t, opened.Cards[0], drawn.Cards[0])
+
+	opened, _ = client.OpenDeck(ctx, d.Id)
+	assert.Equal(t, 1, opened.Remaining)
+	assert.Equal(t, 3, opened.Drawn)
+}
+
+func TestProvablyFairShuffle(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	hash, e := client.CommitServerSeed(ctx)
+	assert.Nil(t, e)
+	d, e := client.CreateDeck(ctx, deck.DeckOptions{Shuffle: true, ClientSeed: "player1", ServerSeedHash: hash})
+	assert.Nil(t, e)
+	assert.Equal(t, hash, d.ServerSeedHash)
+	assert.NotEmpty(t, d.Commitment)
+
+	_, e = client.CreateDeck(ctx, deck.DeckOptions{Shuffle: true, ClientSeed: "player1", ServerSeedHash: hash})
+	assert.NotNil(t, e)
+}
+
+func TestListAndDeleteDecks(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	decks, e := client.ListDecks(ctx)
+	assert.Nil(t, e)
+	assert.Empty(t, decks)
+
+	first, _ := client.CreateDeck(ctx, deck.DeckOptions{Codes: "AS,KD"})
+	second, _ := client.CreateDeck(ctx, deck.DeckOptions{})
+	decks, e = client.ListDecks(ctx)
+	assert.Nil(t, e)
+	assert.Equal(t, []string{first.Id, second.Id}, []string{decks[0].Id, decks[1].Id})
+	assert.Equal(t, 2, decks[0].Remaining)
+	assert.Empty(t, decks[0].Cards)
+
+	// pages of a single deck
+	page, cursor, e := client.ListDecksPage(ctx, 1, "")
+	assert.Nil(t, e)
+	assert.Equal(t, first.Id, page[0].Id)
+	page, cursor, e = client.ListDecksPage(ctx, 1, cursor)
+	assert.Nil(t, e)
+	assert.Equal(t, second.Id, page[0].Id)
+	assert.Empty(t, cursor)
+	_, _, e = client.ListDecksPage(ctx, 0, "")
+	assert.True(t, errors.Is(e, deck.ErrInvalidArgument))
+
+	assert.Nil(t, client.DeleteDeck(ctx, first.Id))
+	decks, _ = client.ListDecks(ctx)
+	assert.Equal(t, 1, len(decks))
+
+	e = client.DeleteDeck(ctx, first.Id)
+	var apiError *Error
+	assert.True(t, errors.As(e, &apiError))
+	assert.Equal(t, http.StatusNotFound, apiError.StatusCode)
+	assert.Equal(t, "deck_not_found", apiError.Problem)
+	assert.Equal(t, "deck api error deck_not_found (status 404): "+apiError.Message, e.Error())
+}
+
+func TestListDecksFollowsPages(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	for i := 0; i < deck.MaxDecksPage+1; i++ {
+		client.CreateDeck(ctx, deck.DeckOptions{Codes: "AS"})
+	}
+	decks, e := client.ListDecks(ctx)
+	assert.Nil(t, e)
+	assert.Equal(t, deck.MaxDecksPage+1, len(decks))
+}
+
+func TestApiErrors(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	var apiError *Error
+
+	_, e := client.CreateDeck(ctx, deck.DeckOptions{Codes: "AS,ZZ"})
+	assert.True(t, errors.As(e, &apiError))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiError.StatusCode)
+	assert.Equal(t, "invalid_card", apiError.Problem)
+	assert.Contains(t, apiError.Message, "ZZ")
+	assert.True(t, errors.Is(e, deck.ErrInvalidCard))
+
+	_, e = client.OpenDeck(ctx, "4c0c167a-5ba6-4437-a09d-9dcb7748df43")
+	assert.True(t, errors.Is(e, deck.ErrDeckNotFound))
+	assert.False(t, errors.Is(e, deck.ErrInvalidDeckId))
+	_, e = client.OpenDeck(ctx, "not-a-uuid")
+	assert.True(t, errors.Is(e, deck.ErrInvalidDeckId))
+
+	d, _ := client.CreateDeck(ctx, deck.DeckOptions{Codes: "AS"})
+	_, e = client.Draw(ctx, d.Id, deck.DrawOptions{Count: 2})
+	assert.True(t, errors.As(e, &apiError))
+	assert.True(t, errors.Is(e, deck.ErrInsufficientCards))
+	assert.Equal(t, "deck api error insufficient_cards (status 409): "+apiError.Message, e.Error())
+
+	// errors without problem details match no kind
+	assert.Nil(t, (&Error{StatusCode: http.StatusBadGateway}).Unwrap())
+	assert.Equal(t, "deck api error 7 (status 400): cannot draw", (&Error{StatusCode: 400, Code: 7, Message: "cannot draw"}).Error())
+}
+
+func TestRetries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := api.NewHandler(deck.NewService(deck.NewMemoryStore()))
+	failures, requests := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := New(server.URL)
+	client.RetryDelay = time.Millisecond
+	ctx := context.Background()
+
+	d, _ := client.CreateDeck(ctx, deck.DeckOptions{})
+
+	// idempotent requests are retried on server errors
+	failures, requests = 2, 0
+	_, e := client.OpenDeck(ctx, d.Id)
+	assert.Nil(t, e)
+	assert.Equal(t, 3, requests)
+
+	failures, requests = 3, 0
+	_, e = client.OpenDeck(ctx, d.Id)
+	var apiError *Error
+	assert.True(t, errors.As(e, &apiError))
+	assert.Equal(t, http.StatusBadGateway, apiError.StatusCode)
+	assert.Equal(t, 3, requests)
+
+	// draws are not, the cards may have been drawn
+	failures, requests = 1, 0
+	_, e = client.Draw(ctx, d.Id, deck.DrawOptions{Count: 1})
+	assert.NotNil(t, e)
+	assert.Equal(t, 1, requests)
+
+	assert.True(t, retryable(&http.Response{StatusCode: http.StatusServiceUnavailable}, nil, false))
+	assert.True(t, retryable(&http.Response{StatusCode: http.StatusTooManyRequests}, nil, false))
+	assert.False(t, retryable(&http.Response{StatusCode: http.StatusBadRequest}, nil, true))
+	assert.True(t, retryable(nil, errors.New("connection refused"), true))
+	assert.False(t, retryable(nil, errors.New("connection refused"), false))
+}
+
+func TestContext(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, e := client.CreateDeck(ctx, deck.DeckOptions{})
+	assert.True(t, errors.Is(e, context.Canceled))
+	_, e = client.OpenDeck(ctx, "4c0c167a-5ba6-4437-a09d-9dcb7748df43")
+	assert.True(t, errors.Is(e, context.Canceled))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+
+	"github.com/ketanbodas/manage-card-deck/client"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/ketanbodas/manage-card-deck/deck/sqlitestore"
+)
+
+// backend of local mode, which manages decks with package deck directly
+type localBackend struct {
+	service *deck.Service
+	// closes the store, if it must be closed
+	close func() error
+}
+
+/*
+Returns the backend of local mode
+inputs:
+	dataDir    :  directory in which decks are kept
+	sqlitePath :  path of SQLite database in which decks are kept, takes precedence over dataDir
+returns:
+	the backend, which must be closed
+	error if the store cannot be opened
+*/
+func newLocalBackend(dataDir string, sqlitePath string) (*localBackend, error) {
+	if len(sqlitePath) > 0 {
+		store, e := sqlitestore.Open(sqlitePath)
+		if e != nil {
+			return nil, e
+		}
+		return &localBackend{service: deck.NewService(store), close: store.Close}, nil
+	}
+	store, e := deck.NewFileStore(dataDir)
+	if e != nil {
+		return nil, e
+	}
+	return &localBackend{service: deck.NewService(store)}, nil
+}
+
+func (b *localBackend) Close() error {
+	if b.close == nil {
+		return nil
+	}
+	return b.close()
+}
+
+func (b *localBackend) CreateDeck(ctx context.Context, options deck.DeckOptions) (client.Deck, error) {
+	d, e := b.service.CreateDeck(options)
+	if e != nil {
+		return client.Deck{}, e
+	}
+	return newDeck(d, false), nil
+}
+
+func (b *localBackend) OpenDeck(ctx context.Context, deckId string) (client.Deck, error) {
+	d, e := b.service.OpenDeck(deckId)
+	if e != nil {
+		return client.Deck{}, e
+	}
+	return newDeck(d, true), nil
+}
+
+func (b *localBackend) Draw(ctx context.Context, deckId string, options deck.DrawOptions) (client.DrawResult, error) {
+	hand, d, e := b.service.Draw(deckId, options)
+	if e != nil {
+		return client.DrawResult{}, e
+	}
+	return client.DrawResult{Cards: hand, Reshuffle: d.NeedsReshuffle()}, nil
+}
+
+func (b *localBackend) ListDecks(ctx context.Context) ([]client.Deck, error) {
+	decks, e := b.service.ListDecks()
+	if e != nil {
+		return nil, e
+	}
+	list := make([]client.Deck, 0, len(decks))
+	for _, d := range decks {
+		list = append(list, newDeck(d, false))
+	}
+	return list, nil
+}
+
+func (b *localBackend) DeleteDeck(ctx context.Context, deckId string) error {
+	return b.service.DeleteDeck(deckId)
+}
+
+// returns the deck as the server returns it, with its cards if withCards is set
+func newDeck(d deck.Deck, withCards bool) client.Deck {
+	c := client.Deck{
+		Id:        d.DeckId.String(),
+		Shuffled:  d.Shuffled,
+		Remaining: len(d.Cards),
+		Seed:      d.Seed,
+		Closed:    d.Closed,
+		Type:      d.Type,
+		CardSet:   d.CardSet,
+		Version:   d.Version,
+		Drawn:     len(d.Drawn),
+	}
+	if d.DecksCount > 1 {
+		c.DecksCount = d.DecksCount
+	}
+	if d.Proof != nil {
+		c.Commitment = d.Proof.Commitment
+	}
+	if len(d.Piles) > 0 {
+		c.Piles = map[string]int{}
+		for name, cards := range d.Piles {
+			c.Piles[name] = len(cards)
+		}
+	}
+	if withCards {
+		c.Cards = d.Cards
+	}
+	return c
+}
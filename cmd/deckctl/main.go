@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ketanbodas/manage-card-deck/client"
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+/*
+deckctl creates, opens, draws from, lists and deletes decks from a terminal,
+either through the api of a running server or with package deck directly in
+local mode, where decks are kept in a data directory or a SQLite database
+*/
+
+const usage = `usage: deckctl [flags] <command> [command flags] [arguments]
+
+commands:
+  create [-shuffle] [-cards AS,KD] [-type piquet] [-jokers 2] [-decks 6] [-seed 7] [-cardset id]
+  open <deck id>
+  draw <deck id> [-n 5] [-from top|bottom|random] [-cards AS,KD] [-until hearts]
+  list
+  delete <deck id>
+
+flags:
+`
+
+// error of the command line, reported with the usage
+var errUsage = errors.New("invalid usage")
+
+// operations on decks, implemented by client.Client and by localBackend
+type backend interface {
+	CreateDeck(ctx context.Context, options deck.DeckOptions) (client.Deck, error)
+	OpenDeck(ctx context.Context, deckId string) (client.Deck, error)
+	Draw(ctx context.Context, deckId string, options deck.DrawOptions) (client.DrawResult, error)
+	ListDecks(ctx context.Context) ([]client.Deck, error)
+	DeleteDeck(ctx context.Context, deckId string) error
+}
+
+// runs a command with the given arguments and output
+type command func(ctx context.Context, b backend, args []string, out printer) error
+
+var commands = map[string]command{
+	"create": create,
+	"open":   open,
+	"draw":   draw,
+	"list":   list,
+	"delete": remove,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+/*
+Runs deckctl
+inputs:
+	args   :  command line arguments without the program name
+	stdout :  writer of the output of the command
+	stderr :  writer of errors and usage
+returns:
+	exit code, 1 if the command fails and 2 if the command line is invalid
+*/
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("deckctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	server := flags.String("server", serverFromEnv(), "URL of the deck server, $DECKCTL_SERVER if set")
+	local := flags.Bool("local", false, "manage decks with package deck directly instead of a server")
+	dataDir := flags.String("data-dir", ".deckctl", "directory in which decks are kept in local mode")
+	sqlitePath := flags.String("sqlite", "", "path of SQLite database in which decks are kept in local mode, takes precedence over -data-dir")
+	output := flags.String("output", "pretty", "output format, pretty or json")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of a command")
+	if e := flags.Parse(args); e != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	cmd, exists := commands[flags.Arg(0)]
+	if !exists {
+		fmt.Fprintf(stderr, "deckctl: unknown command '%v'\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+	out, e := newPrinter(*output, stdout)
+	if e != nil {
+		fmt.Fprintf(stderr, "deckctl: %v\n", e)
+		return 2
+	}
+
+	var b backend
+	if *local {
+		localBackend, e := newLocalBackend(*dataDir, *sqlitePath)
+		if e != nil {
+			fmt.Fprintf(stderr, "deckctl: %v\n", e)
+			return 1
+		}
+		defer localBackend.Close()
+		b = localBackend
+	} else {
+		b = client.New(*server)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if e := cmd(ctx, b, flags.Args()[1:], out); e != nil {
+		fmt.Fprintf(stderr, "deckctl: %v\n", e)
+		if errors.Is(e, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// returns the URL of the server from $DECKCTL_SERVER, the default address of the server if not set
+func serverFromEnv() string {
+	if server := os.Getenv("DECKCTL_SERVER"); len(server) > 0 {
+		return server
+	}
+	return "http://localhost:3000"
+}
+
+// create a new deck
+func create(ctx context.Context, b backend, args []string, out printer) error {
+	flags := newFlagSet("create")
+	shuffle := flags.Bool("shuffle", false, "shuffle the new deck")
+	cards := flags.String("cards", "", "comma separated card codes of a partial deck")
+	deckType := flags.String("type", "", "template of the deck, e.g. piquet")
+	jokers := flags.Int("jokers", 0, "number of jokers added to the deck")
+	decks := flags.Int("decks", 0, "number of decks in a shoe")
+	seed := flags.Int64("seed", 0, "seed of the shuffle, so that it can be reproduced")
+	cardSet := flags.String("cardset", "", "id of a registered card set")
+	arguments, e := parseFlags(flags, args)
+	if e != nil {
+		return e
+	}
+	if len(arguments) > 0 {
+		return fmt.Errorf("%w: create takes no arguments", errUsage)
+	}
+
+	options := deck.DeckOptions{
+		Shuffle:    *shuffle,
+		Codes:      *cards,
+		Type:       *deckType,
+		Jokers:     *jokers,
+		DecksCount: *decks,
+		CardSet:    *cardSet,
+	}
+	if isSet(flags, "seed") {
+		options.Seed = seed
+	}
+	d, e := b.CreateDeck(ctx, options)
+	if e != nil {
+		return e
+	}
+	return out.deck(d)
+}
+
+// open a deck and show its remaining cards
+func open(ctx context.Context, b backend, args []string, out printer) error {
+	deckId, e := parseDeckId(newFlagSet("open"), args)
+	if e != nil {
+		return e
+	}
+	d, e := b.OpenDeck(ctx, deckId)
+	if e != nil {
+		return e
+	}
+	return out.deck(d)
+}
+
+// draw cards from a deck
+func draw(ctx context.Context, b backend, args []string, out printer) error {
+	flags := newFlagSet("draw")
+	count := flags.Int("n", 0, "number of cards to draw, 1 if neither -cards nor -until is given")
+	from := flags.String("from", "", "top, bottom or random, top by default")
+	cards := flags.String("cards", "", "comma separated codes of the cards to draw")
+	until := flags.String("until", "", "draw until a card matching the predicate, e.g. hearts, red or QH")
+	deckId, e := parseDeckId(flags, args)
+	if e != nil {
+		return e
+	}
+
+	options := deck.DrawOptions{Count: *count, From: *from, Codes: *cards, Until: *until}
+	if options.Count == 0 && len(options.Codes) == 0 && len(options.Until) == 0 {
+		options.Count = 1
+	}
+	result, e := b.Draw(ctx, deckId, options)
+	if e != nil {
+		return e
+	}
+	return out.drawn(result)
+}
+
+// list all decks
+func list(ctx context.Context, b backend, args []string, out printer) error {
+	arguments, e := parseFlags(newFlagSet("list"), args)
+	if e != nil {
+		return e
+	}
+	if len(arguments) > 0 {
+		return fmt.Errorf("%w: list takes no arguments", errUsage)
+	}
+	decks, e := b.ListDecks(ctx)
+	if e != nil {
+		return e
+	}
+	return out.decks(decks)
+}
+
+// delete a deck
+func remove(ctx context.Context, b backend, args []string, out printer) error {
+	deckId, e := parseDeckId(newFlagSet("delete"), args)
+	if e != nil {
+		return e
+	}
+	if e := b.DeleteDeck(ctx, deckId); e != nil {
+		return e
+	}
+	return out.deleted(deckId)
+}
+
+// returns the flags of a command, errors are returned instead of printed
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+/*
+Parses the flags of a command, which may come before or after its
+arguments, e.g. draw <deck id> -n 5. Returns the arguments
+*/
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	arguments := []string{}
+	for {
+		if e := flags.Parse(args); e != nil {
+			return nil, fmt.Errorf("%w: %v %v", errUsage, flags.Name(), e)
+		}
+		if flags.NArg() == 0 {
+			return arguments, nil
+		}
+		arguments = append(arguments, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// parses the flags of a command which takes a deck id as its only argument
+func parseDeckId(flags *flag.FlagSet, args []string) (string, error) {
+	arguments, e := parseFlags(flags, args)
+	if e != nil {
+		return "", e
+	}
+	if len(arguments) != 1 || len(strings.TrimSpace(arguments[0])) == 0 {
+		return "", fmt.Errorf("%w: %v takes a deck id", errUsage, flags.Name())
+	}
+	return arguments[0], nil
+}
+
+// returns true if the flag was given on the command line
+func isSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
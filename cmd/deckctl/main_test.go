@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ketanbodas/manage-card-deck/api"
+	"github.com/ketanbodas/manage-card-deck/client"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+// runs deckctl and returns its exit code, output and errors
+func runDeckctl(args ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// runs the commands of deckctl against a backend selected by the flags
+func assertCommands(t *testing.T, flags ...string) {
+	code, stdout, _ := runDeckctl(append(flags, "-output", "json", "create", "--shuffle", "--cards", "AS,KD,10H,2C", "-seed", "3")...)
+	assert.Equal(t, 0, code)
+	created := client.Deck{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &created))
+	assert.Equal(t, 4, created.Remaining)
+	assert.True(t, created.Shuffled)
+	assert.Equal(t, int64(3), *created.Seed)
+
+	code, stdout, _ = runDeckctl(append(flags, "-output", "json", "open", created.Id)...)
+	assert.Equal(t, 0, code)
+	opened := client.Deck{}
+	json.Unmarshal([]byte(stdout), &opened)
+	assert.Equal(t, 4, len(opened.Cards))
+
+	code, stdout, _ = runDeckctl(append(flags, "draw", created.Id, "-n", "2")...)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, glyph(opened.Cards[0])+" "+glyph(opened.Cards[1])+"\n", stdout)
+
+	code, stdout, _ = runDeckctl(append(flags, "open", created.Id)...)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "remaining  2\n")
+	assert.Contains(t, stdout, "drawn      2\n")
+	assert.True(t, strings.HasSuffix(stdout, glyph(opened.Cards[2])+" "+glyph(opened.Cards[3])+"\n"))
+
+	code, stdout, _ = runDeckctl(append(flags, "list")...)
+	assert.Equal(t, 0, code)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, []string{"DECK", "REMAINING", "DRAWN", "SHUFFLED", "CLOSED", "TYPE"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{created.Id, "2", "2", "yes", "no", "standard"}, strings.Fields(lines[1]))
+
+	code, stdout, _ = runDeckctl(append(flags, "delete", created.Id)...)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "deleted deck "+created.Id+"\n", stdout)
+
+	code, _, stderr := runDeckctl(append(flags, "open", created.Id)...)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "deck not found")
+
+	code, stdout, _ = runDeckctl(append(flags, "-output", "json", "list")...)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "[]\n", stdout)
+
+	// draw up to the first heart as the usage shows
+	code, stdout, _ = runDeckctl(append(flags, "-output", "json", "create", "--cards", "2C,10H,AS")...)
+	assert.Equal(t, 0, code)
+	json.Unmarshal([]byte(stdout), &created)
+	code, stdout, _ = runDeckctl(append(flags, "draw", created.Id, "-until", "hearts")...)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "2♣ 10♥\n", stdout)
+}
+
+func TestLocalMode(t *testing.T) {
+	assertCommands(t, "-local", "-data-dir", t.TempDir())
+}
+
+func TestRemoteMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := httptest.NewServer(api.NewHandler(deck.NewService(deck.NewMemoryStore())))
+	defer server.Close()
+	assertCommands(t, "-server", server.URL)
+}
+
+func TestUsage(t *testing.T) {
+	dataDir := t.TempDir()
+	code, _, stderr := runDeckctl()
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: deckctl")
+
+	code, _, stderr = runDeckctl("-local", "-data-dir", dataDir, "shuffle")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown command 'shuffle'")
+
+	code, _, stderr = runDeckctl("-local", "-data-dir", dataDir, "draw")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "draw takes a deck id")
+
+	code, _, _ = runDeckctl("-local", "-data-dir", dataDir, "draw", "id", "-n", "two")
+	assert.Equal(t, 2, code)
+	code, _, _ = runDeckctl("-local", "-data-dir", dataDir, "list", "extra")
+	assert.Equal(t, 2, code)
+	code, _, stderr = runDeckctl("-local", "-data-dir", dataDir, "-output", "yaml", "list")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "output format 'yaml' is invalid")
+
+	code, _, stderr = runDeckctl("-local", "-data-dir", dataDir, "create", "-cards", "AS,ZZ")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "ZZ")
+}
+
+func TestGlyph(t *testing.T) {
+	for code, expected := range map[string]string{"AS": "A♠", "10H": "10♥", "QD": "Q♦", "2C": "2♣", "X": "🃏"} {
+		card, _ := deck.ParseCard(code)
+		assert.Equal(t, expected, glyph(card))
+	}
+	set, _ := deck.RegisterCardSet(deck.CardSet{Name: "tarot", Cards: []deck.CardDefinition{{Code: "T0"}}})
+	assert.Equal(t, "T0", glyph(deck.NewCustomCard(set.Id, set.Cards[0])))
+}
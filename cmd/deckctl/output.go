@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ketanbodas/manage-card-deck/client"
+	"github.com/ketanbodas/manage-card-deck/deck"
+)
+
+// number of cards shown in a row of pretty output
+const cardsPerRow = 13
+
+// writes the results of commands as JSON or as tables with card glyphs
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// returns the printer of the output format, pretty or json
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "pretty":
+		return printer{w: w}, nil
+	case "json":
+		return printer{w: w, json: true}, nil
+	}
+	return printer{}, fmt.Errorf("output format '%v' is invalid, should be pretty or json", format)
+}
+
+// writes a deck with its details and cards
+func (p printer) deck(d client.Deck) error {
+	if p.json {
+		return p.writeJSON(d)
+	}
+	t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(t, "deck\t%v\n", d.Id)
+	fmt.Fprintf(t, "shuffled\t%v\n", yesNo(d.Shuffled))
+	fmt.Fprintf(t, "remaining\t%v\n", d.Remaining)
+	fmt.Fprintf(t, "drawn\t%v\n", d.Drawn)
+	if d.Closed {
+		fmt.Fprintf(t, "closed\tyes\n")
+	}
+	if len(d.Type) > 0 {
+		fmt.Fprintf(t, "type\t%v\n", d.Type)
+	}
+	if len(d.CardSet) > 0 {
+		fmt.Fprintf(t, "cardset\t%v\n", d.CardSet)
+	}
+	if d.DecksCount > 1 {
+		fmt.Fprintf(t, "decks\t%v\n", d.DecksCount)
+	}
+	if d.Seed != nil {
+		fmt.Fprintf(t, "seed\t%v\n", *d.Seed)
+	}
+	if len(d.Piles) > 0 {
+		fmt.Fprintf(t, "piles\t%v\n", pileList(d.Piles))
+	}
+	fmt.Fprintf(t, "version\t%v\n", d.Version)
+	if e := t.Flush(); e != nil {
+		return e
+	}
+	return p.writeCards(d.Cards)
+}
+
+// writes drawn cards
+func (p printer) drawn(result client.DrawResult) error {
+	if p.json {
+		return p.writeJSON(result)
+	}
+	if e := p.writeCards(result.Cards); e != nil {
+		return e
+	}
+	if result.Reshuffle {
+		_, e := fmt.Fprintln(p.w, "cut card reached, the shoe should be reshuffled")
+		return e
+	}
+	return nil
+}
+
+// writes a table of decks
+func (p printer) decks(decks []client.Deck) error {
+	if p.json {
+		return p.writeJSON(decks)
+	}
+	t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "DECK\tREMAINING\tDRAWN\tSHUFFLED\tCLOSED\tTYPE")
+	for _, d := range decks {
+		deckType := d.Type
+		if len(d.CardSet) > 0 {
+			deckType = d.CardSet
+		}
+		fmt.Fprintf(t, "%v\t%v\t%v\t%v\t%v\t%v\n", d.Id, d.Remaining, d.Drawn, yesNo(d.Shuffled), yesNo(d.Closed), deckType)
+	}
+	return t.Flush()
+}
+
+// writes the id of a deleted deck
+func (p printer) deleted(deckId string) error {
+	if p.json {
+		return p.writeJSON(map[string]interface{}{"deck_id": deckId, "deleted": true})
+	}
+	_, e := fmt.Fprintf(p.w, "deleted deck %v\n", deckId)
+	return e
+}
+
+func (p printer) writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(v)
+}
+
+// writes the glyphs of the cards in rows
+func (p printer) writeCards(cards []deck.Card) error {
+	for start := 0; start < len(cards); start += cardsPerRow {
+		end := start + cardsPerRow
+		if end > len(cards) {
+			end = len(cards)
+		}
+		glyphs := make([]string, 0, end-start)
+		for _, card := range cards[start:end] {
+			glyphs = append(glyphs, glyph(card))
+		}
+		if _, e := fmt.Fprintln(p.w, strings.Join(glyphs, " ")); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// returns the glyph of a card, e.g. A♠ or 10♥, the code of a custom card
+func glyph(card deck.Card) string {
+	if card.IsCustom() {
+		return card.Code()
+	}
+	if card.IsJoker() {
+		return "🃏"
+	}
+	return card.Rank.Code() + card.Suit.Symbol()
+}
+
+// returns the piles with their number of cards ordered by name, e.g. discard: 2, player-1: 5
+func pileList(piles map[string]int) string {
+	names := make([]string, 0, len(piles))
+	for name := range piles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		list = append(list, fmt.Sprintf("%v: %v", name, piles[name]))
+	}
+	return strings.Join(list, ", ")
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
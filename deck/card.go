@@ -0,0 +1,341 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+Name of the diamonds suit in the json of a card. Older versions spelled it
+DIMONDS, which is kept so that existing clients do not break. Parsing accepts
+both spellings. The api can correct it, see api.WithCorrectSuitNames
+*/
+const LegacyDiamondsName = "DIMONDS"
+
+// type to represent the suit of a card, suits are ordered as in a new deck
+type Suit int
+
+const (
+	NoSuit Suit = iota
+	Spades
+	Diamonds
+	Clubs
+	Hearts
+)
+
+// type to represent the rank of a card, the value of a rank is its number
+// with ace as 1, jack, queen and king as 11, 12 and 13 and joker as 14
+type Rank int
+
+const (
+	NoRank Rank = iota
+	Ace
+	Two
+	Three
+	Four
+	Five
+	Six
+	Seven
+	Eight
+	Nine
+	Ten
+	Jack
+	Queen
+	King
+	Joker
+)
+
+// type to represent the color of a suit
+type Color int
+
+const (
+	NoColor Color = iota
+	Black
+	Red
+)
+
+/*
+Type to represent a single Card, a joker has no suit.
+A card of a custom card set has no rank and suit, it is described by its
+definition instead, see RegisterCardSet
+*/
+type Card struct {
+	Rank Rank
+	Suit Suit
+	// id of the card set of a custom card, empty for standard cards
+	CardSet string
+	// definition of a custom card, nil for standard cards. Shared, must not be modified
+	Custom *CardDefinition
+}
+
+// list of suits and ranks in the order of a new deck
+var suits = []Suit{Spades, Diamonds, Clubs, Hearts}
+var ranks = []Rank{Ace, Two, Three, Four, Five, Six, Seven, Eight, Nine, Ten, Jack, Queen, King}
+
+// details of each suit, indexed by suit
+var suitLetters = []string{"", "S", "D", "C", "H"}
+var suitNames = []string{"", "SPADES", "DIAMONDS", "CLUBS", "HEARTS"}
+var suitSymbols = []string{"", "♠", "♦", "♣", "♥"}
+var suitColors = []Color{NoColor, Black, Red, Black, Red}
+
+// details of each rank, indexed by rank
+var rankCodes = []string{"", "A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "X"}
+var rankNames = []string{"", "ACE", "2", "3", "4", "5", "6", "7", "8", "9", "10", "JACK", "QUEEN", "KING", "JOKER"}
+
+// spellings of suits accepted by ParseCard, besides the suit letters
+var suitAliases = map[string]Suit{
+	"♤": Spades,
+	"♢": Diamonds,
+	"♧": Clubs,
+	"♡": Hearts,
+}
+
+// spellings of ranks accepted by ParseCard, besides the rank codes
+var rankAliases = map[string]Rank{
+	"T": Ten,
+}
+
+// spellings of a joker accepted by ParseCard, a joker has no suit
+var jokerCodes = map[string]bool{
+	"X":  true,
+	"🃏": true,
+}
+
+// returns true for the four suits of a card deck
+func (s Suit) IsValid() bool {
+	return s > NoSuit && s <= Hearts
+}
+
+// returns the single letter used in card codes, e.g. S
+func (s Suit) Letter() string {
+	if !s.IsValid() {
+		return ""
+	}
+	return suitLetters[s]
+}
+
+// returns the name of the suit, e.g. SPADES or DIAMONDS
+func (s Suit) Name() string {
+	if !s.IsValid() {
+		return ""
+	}
+	return suitNames[s]
+}
+
+// returns the name of the suit in the json of a card, see LegacyDiamondsName
+func (s Suit) jsonName() string {
+	if s == Diamonds {
+		return LegacyDiamondsName
+	}
+	return s.Name()
+}
+
+// returns the unicode symbol of the suit, e.g. ♠
+func (s Suit) Symbol() string {
+	if !s.IsValid() {
+		return ""
+	}
+	return suitSymbols[s]
+}
+
+// returns black for spades and clubs, red for diamonds and hearts
+func (s Suit) Color() Color {
+	if !s.IsValid() {
+		return NoColor
+	}
+	return suitColors[s]
+}
+
+func (s Suit) String() string {
+	return s.Name()
+}
+
+func (c Color) String() string {
+	switch c {
+	case Black:
+		return "BLACK"
+	case Red:
+		return "RED"
+	}
+	return ""
+}
+
+// returns true for ranks ace to king and joker
+func (r Rank) IsValid() bool {
+	return r > NoRank && r <= Joker
+}
+
+// returns the rank as used in card codes, e.g. A or 10
+func (r Rank) Code() string {
+	if !r.IsValid() {
+		return ""
+	}
+	return rankCodes[r]
+}
+
+// returns the name of the rank, e.g. ACE or 10
+func (r Rank) Name() string {
+	if !r.IsValid() {
+		return ""
+	}
+	return rankNames[r]
+}
+
+// returns true for jack, queen and king
+func (r Rank) IsFace() bool {
+	return r >= Jack && r <= King
+}
+
+func (r Rank) String() string {
+	return r.Name()
+}
+
+/*
+Parses a card code and returns the card.
+The rank is followed by the suit, both are case insensitive. Besides the
+canonical codes (AS, 10H, KD) a ten can be written as T (TS), and the suit
+as unicode symbol (10♠, Q♥). A joker is X
+inputs:
+	code :  the card code, surrounding spaces are ignored
+returns:
+	the card
+	error if code does not have a valid rank and suit
+*/
+func ParseCard(code string) (Card, error) {
+	code = strings.TrimSpace(code)
+	if jokerCodes[strings.ToUpper(code)] {
+		return Card{Rank: Joker}, nil
+	}
+	runes := []rune(strings.ToUpper(code))
+	if len(runes) < 2 {
+		message := fmt.Sprintf("code %v is invalid", code)
+		return Card{}, newError(ErrInvalidCard, message)
+	}
+
+	suit := parseSuit(string(runes[len(runes)-1]))
+	if suit == NoSuit {
+		message := fmt.Sprintf("code %v is invalid, should have proper suit name", code)
+		return Card{}, newError(ErrInvalidCard, message)
+	}
+
+	rank := parseRank(string(runes[:len(runes)-1]))
+	if rank == NoRank {
+		message := fmt.Sprintf("code %v is invalid, should have proper card value", code)
+		return Card{}, newError(ErrInvalidCard, message)
+	}
+
+	return Card{Rank: rank, Suit: suit}, nil
+}
+
+// returns the suit for an upper case letter or symbol, NoSuit if unknown
+func parseSuit(s string) Suit {
+	for _, suit := range suits {
+		if s == suit.Letter() || s == suit.Symbol() {
+			return suit
+		}
+	}
+	return suitAliases[s]
+}
+
+// returns the rank for an upper case rank code, NoRank if unknown or joker
+func parseRank(s string) Rank {
+	for _, rank := range ranks {
+		if s == rank.Code() {
+			return rank
+		}
+	}
+	return rankAliases[s]
+}
+
+/*
+Returns the canonical code of the card, e.g. AS or 10H, X for a joker.
+A custom card returns the code of its definition
+*/
+func (c Card) Code() string {
+	if c.Custom != nil {
+		return c.Custom.Code
+	}
+	return c.Rank.Code() + c.Suit.Letter()
+}
+
+// returns the display name of the card, e.g. ACE or the name of a custom card
+func (c Card) Name() string {
+	if c.Custom != nil {
+		return c.Custom.Name
+	}
+	return c.Rank.Name()
+}
+
+// returns true if the card belongs to a custom card set
+func (c Card) IsCustom() bool {
+	return c.Custom != nil
+}
+
+// returns true if both cards are the same card, i.e. have the same code in the same card set
+func (c Card) Equal(other Card) bool {
+	return c.CardSet == other.CardSet && c.Code() == other.Code()
+}
+
+/*
+Returns the canonical code of the card, which ParseCard parses back to the same card
+*/
+func (c Card) String() string {
+	return c.Code()
+}
+
+// returns true if the card is a joker
+func (c Card) IsJoker() bool {
+	return c.Rank == Joker
+}
+
+// returns true if the card comes before other in a new deck, i.e. by suit and
+// then by rank with jokers last
+func (c Card) Less(other Card) bool {
+	if c.IsJoker() != other.IsJoker() {
+		return other.IsJoker()
+	}
+	if c.Suit != other.Suit {
+		return c.Suit < other.Suit
+	}
+	return c.Rank < other.Rank
+}
+
+// json representation of a card, as returned by the api
+type cardJSON struct {
+	Value      string                 `json:"value"`
+	Suit       string                 `json:"suit"`
+	Code       string                 `json:"code"`
+	CardSet    string                 `json:"cardset,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func (c Card) MarshalJSON() ([]byte, error) {
+	j := cardJSON{Value: c.Name(), Suit: c.Suit.jsonName(), Code: c.Code(), CardSet: c.CardSet}
+	if c.Custom != nil {
+		j.Attributes = c.Custom.Attributes
+	}
+	return json.Marshal(j)
+}
+
+/*
+Reads a card from its json representation. A standard card is identified by
+its code, a custom card is read with its name and attributes so that it does
+not depend on the card set being registered
+*/
+func (c *Card) UnmarshalJSON(data []byte) error {
+	var j cardJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if len(j.CardSet) > 0 {
+		*c = NewCustomCard(j.CardSet, CardDefinition{Code: j.Code, Name: j.Value, Attributes: j.Attributes})
+		return nil
+	}
+	card, err := ParseCard(j.Code)
+	if err != nil {
+		return err
+	}
+	*c = card
+	return nil
+}
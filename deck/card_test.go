@@ -0,0 +1,162 @@
+package deck
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// all spellings of suits accepted by ParseCard
+var suitSpellings = map[Suit][]string{
+	Spades:   {"S", "s", "♠", "♤"},
+	Diamonds: {"D", "d", "♦", "♢"},
+	Clubs:    {"C", "c", "♣", "♧"},
+	Hearts:   {"H", "h", "♥", "♡"},
+}
+
+// a card together with one of the ways it can be written
+type cardSpelling struct {
+	Card Card
+	Code string
+}
+
+// generates random cards with a random spelling, for property tests
+func (cardSpelling) Generate(r *rand.Rand, size int) reflect.Value {
+	cards := newSequentialDeck().Cards
+	card := cards[r.Intn(len(cards))]
+
+	value := card.Rank.Code()
+	if card.Rank == Ten && r.Intn(2) == 0 {
+		value = "T"
+	}
+	if r.Intn(2) == 0 {
+		value = strings.ToLower(value)
+	}
+	spellings := suitSpellings[card.Suit]
+	code := value + spellings[r.Intn(len(spellings))]
+	if r.Intn(4) == 0 {
+		code = " " + code + " "
+	}
+	return reflect.ValueOf(cardSpelling{Card: card, Code: code})
+}
+
+func TestParseFormatRoundTripAllCards(t *testing.T) {
+	for _, card := range newSequentialDeck().Cards {
+		parsed, error := ParseCard(card.String())
+		assert.Nil(t, error)
+		assert.Equal(t, card, parsed)
+	}
+}
+
+func TestParseCardProperties(t *testing.T) {
+	// any spelling of a card parses to the card
+	parsesToCard := func(s cardSpelling) bool {
+		parsed, error := ParseCard(s.Code)
+		return error == nil && parsed == s.Card
+	}
+	assert.Nil(t, quick.Check(parsesToCard, nil))
+
+	// formatting a parsed card and parsing it again gives the same card
+	roundTrips := func(s cardSpelling) bool {
+		parsed, _ := ParseCard(s.Code)
+		reparsed, error := ParseCard(parsed.String())
+		return error == nil && reparsed == parsed
+	}
+	assert.Nil(t, quick.Check(roundTrips, nil))
+}
+
+func TestParseCard(t *testing.T) {
+	card, error := ParseCard("t♣")
+	assert.Nil(t, error)
+	assert.Equal(t, Card{Rank: Ten, Suit: Clubs}, card)
+
+	card, error = ParseCard("q♡")
+	assert.Nil(t, error)
+	assert.Equal(t, Card{Rank: Queen, Suit: Hearts}, card)
+
+	card, error = ParseCard(" x ")
+	assert.Nil(t, error)
+	assert.Equal(t, Card{Rank: Joker}, card)
+	assert.Equal(t, "X", card.Code())
+	assert.True(t, card.IsJoker())
+
+	for _, code := range []string{"", "S", "1S", "11S", "TT", "10X", "A♠♠", "☃S", "XS", "XX"} {
+		_, error := ParseCard(code)
+		assert.NotNil(t, error, code)
+	}
+}
+
+func TestSuitMethods(t *testing.T) {
+	assert.Equal(t, "S", Spades.Letter())
+	assert.Equal(t, "♦", Diamonds.Symbol())
+	assert.Equal(t, "CLUBS", Clubs.Name())
+	assert.Equal(t, "DIAMONDS", Diamonds.Name())
+	assert.Equal(t, "HEARTS", Hearts.String())
+	assert.Equal(t, Black, Spades.Color())
+	assert.Equal(t, Red, Diamonds.Color())
+	assert.Equal(t, Black, Clubs.Color())
+	assert.Equal(t, Red, Hearts.Color())
+	assert.Equal(t, "RED", Hearts.Color().String())
+	assert.True(t, Spades < Hearts)
+	assert.False(t, NoSuit.IsValid())
+	assert.Equal(t, "", Suit(9).Name())
+	assert.Equal(t, NoColor, NoSuit.Color())
+}
+
+func TestRankMethods(t *testing.T) {
+	assert.Equal(t, "A", Ace.Code())
+	assert.Equal(t, "ACE", Ace.Name())
+	assert.Equal(t, "10", Ten.Code())
+	assert.Equal(t, "QUEEN", Queen.String())
+	assert.Equal(t, 1, int(Ace))
+	assert.Equal(t, 13, int(King))
+	assert.Equal(t, Jack, Ten+1)
+	assert.True(t, King.IsFace())
+	assert.False(t, Ten.IsFace())
+	assert.True(t, Joker.IsValid())
+	assert.False(t, Rank(15).IsValid())
+	assert.Equal(t, "", NoRank.Code())
+}
+
+func TestCardOrdering(t *testing.T) {
+	cards := newSequentialDeck().Cards
+	for i := 1; i < len(cards); i++ {
+		assert.True(t, cards[i-1].Less(cards[i]))
+		assert.False(t, cards[i].Less(cards[i-1]))
+	}
+}
+
+func TestCardJSONWireFormat(t *testing.T) {
+	data, error := json.Marshal([]Card{{Rank: Ace, Suit: Spades}, {Rank: Ten, Suit: Diamonds}})
+	assert.Nil(t, error)
+	assert.JSONEq(t, `[
+		{"value": "ACE", "suit": "SPADES", "code": "AS"},
+		{"value": "10", "suit": "DIMONDS", "code": "10D"}
+	]`, string(data))
+}
+
+func TestCardJSONRoundTrip(t *testing.T) {
+	cards := newSequentialDeck().Cards
+	data, _ := json.Marshal(cards)
+	decoded := []Card{}
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, cards, decoded)
+
+	var card Card
+	assert.NotNil(t, json.Unmarshal([]byte(`{"value": "ACE", "suit": "SPADES", "code": "ZZ"}`), &card))
+}
+
+func TestJokerJSON(t *testing.T) {
+	data, _ := json.Marshal(Card{Rank: Joker})
+	assert.JSONEq(t, `{"value": "JOKER", "suit": "", "code": "X"}`, string(data))
+
+	var card Card
+	assert.Nil(t, json.Unmarshal(data, &card))
+	assert.Equal(t, Card{Rank: Joker}, card)
+	assert.True(t, Card{Rank: King, Suit: Hearts}.Less(card))
+}
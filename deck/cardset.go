@@ -0,0 +1,177 @@
+package deck
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// returned when no card set is registered with the requested id
+var ErrCardSetNotFound = errors.New("card set not found")
+
+// maximum number of registered card sets
+const MaxCardSets = 1000
+
+// maximum number of cards of a card set
+const MaxCardSetSize = 1000
+
+// type to describe a card of a custom card set
+type CardDefinition struct {
+	// unique code of the card within its set, e.g. T0 or RED-7
+	Code string `json:"code"`
+	// display name, the code if empty
+	Name string `json:"name"`
+	// arbitrary attributes of the card, e.g. color or power
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+/*
+Type to describe a custom set of cards, e.g. a tarot or a trading card deck.
+A new deck of the set has all its cards in the order they are defined
+*/
+type CardSet struct {
+	Id    string           `json:"id"`
+	Name  string           `json:"name"`
+	Cards []CardDefinition `json:"cards"`
+}
+
+// registry of custom card sets by id
+var cardSets = struct {
+	sync.RWMutex
+	sets map[string]CardSet
+}{sets: map[string]CardSet{}}
+
+/*
+Validates and registers a custom card set under a new id
+inputs:
+	set :  the card set, its id is ignored
+returns:
+	the registered card set with its id
+	error if the set has no cards or more than MaxCardSetSize, a code is
+	empty, duplicated or contains a comma or space, or MaxCardSets are
+	registered already
+*/
+func RegisterCardSet(set CardSet) (CardSet, error) {
+	if len(set.Cards) == 0 {
+		return set, newError(ErrInvalidCardSet, "card set must have at least one card")
+	}
+	if len(set.Cards) > MaxCardSetSize {
+		message := fmt.Sprintf("card set has %d cards, should have at most %d", len(set.Cards), MaxCardSetSize)
+		return set, newError(ErrInvalidCardSet, message)
+	}
+	definitions := make([]CardDefinition, len(set.Cards))
+	codes := map[string]bool{}
+	for i, definition := range set.Cards {
+		if len(definition.Code) == 0 || strings.ContainsAny(definition.Code, ", \t\n") {
+			message := fmt.Sprintf("code '%v' of card %d is invalid, should be non empty without commas and spaces", definition.Code, i)
+			return set, newError(ErrInvalidCardSet, message)
+		}
+		if codes[definition.Code] {
+			message := fmt.Sprintf("code %v is used by more than one card", definition.Code)
+			return set, newError(ErrInvalidCardSet, message)
+		}
+		codes[definition.Code] = true
+		if len(definition.Name) == 0 {
+			definition.Name = definition.Code
+		}
+		definition.Attributes = copyAttributes(definition.Attributes)
+		definitions[i] = definition
+	}
+	set.Id = uuid.NewString()
+	set.Cards = definitions
+
+	cardSets.Lock()
+	defer cardSets.Unlock()
+	if len(cardSets.sets) >= MaxCardSets {
+		message := fmt.Sprintf("%d card sets are registered already, no more can be registered", MaxCardSets)
+		return set, newError(ErrInvalidCardSet, message)
+	}
+	cardSets.sets[set.Id] = set
+	return set, nil
+}
+
+/*
+Returns the registered card set with given id
+inputs:
+	id :  id returned by RegisterCardSet
+returns:
+	the card set
+	error wrapping ErrCardSetNotFound if no set is registered with the id
+*/
+func GetCardSet(id string) (CardSet, error) {
+	cardSets.RLock()
+	defer cardSets.RUnlock()
+	set, exists := cardSets.sets[id]
+	if !exists {
+		return set, fmt.Errorf("%w for the id %v", ErrCardSetNotFound, id)
+	}
+	return set, nil
+}
+
+// returns all registered card sets ordered by name and id
+func CardSets() []CardSet {
+	cardSets.RLock()
+	sets := []CardSet{}
+	for _, set := range cardSets.sets {
+		sets = append(sets, set)
+	}
+	cardSets.RUnlock()
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Name != sets[j].Name {
+			return sets[i].Name < sets[j].Name
+		}
+		return sets[i].Id < sets[j].Id
+	})
+	return sets
+}
+
+// returns a card of the given card set with given definition
+func NewCustomCard(cardSet string, definition CardDefinition) Card {
+	return Card{CardSet: cardSet, Custom: &definition}
+}
+
+// returns all cards of the set in the order they are defined
+func (set CardSet) newCards() []Card {
+	cards := make([]Card, len(set.Cards))
+	for i, definition := range set.Cards {
+		cards[i] = NewCustomCard(set.Id, definition)
+	}
+	return cards
+}
+
+/*
+Returns the cards of the set with given comma separated codes, in the order
+of the codes. Codes of custom cards are case sensitive
+*/
+func (set CardSet) cardsFromCodes(codes string) ([]Card, error) {
+	definitions := map[string]CardDefinition{}
+	for _, definition := range set.Cards {
+		definitions[definition.Code] = definition
+	}
+	cards := []Card{}
+	for _, code := range strings.Split(codes, ",") {
+		definition, exists := definitions[strings.TrimSpace(code)]
+		if !exists {
+			message := fmt.Sprintf("code %v is invalid, card is not part of card set %v", strings.TrimSpace(code), set.Id)
+			return nil, newError(ErrInvalidCard, message)
+		}
+		cards = append(cards, NewCustomCard(set.Id, definition))
+	}
+	return cards, nil
+}
+
+// returns a shallow copy of the attributes, nil if there are none
+func copyAttributes(attributes map[string]interface{}) map[string]interface{} {
+	if len(attributes) == 0 {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(attributes))
+	for key, value := range attributes {
+		copied[key] = value
+	}
+	return copied
+}
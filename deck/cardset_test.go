@@ -0,0 +1,135 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// registers a small set of numbered cards with a color attribute
+func registerTestCardSet(t *testing.T) CardSet {
+	set, error := RegisterCardSet(CardSet{
+		Name: "colors",
+		Cards: []CardDefinition{
+			{Code: "R1", Name: "Red One", Attributes: map[string]interface{}{"color": "red", "number": 1}},
+			{Code: "G1", Name: "Green One", Attributes: map[string]interface{}{"color": "green", "number": 1}},
+			{Code: "WILD"},
+		},
+	})
+	assert.Nil(t, error)
+	return set
+}
+
+func TestRegisterCardSet(t *testing.T) {
+	set := registerTestCardSet(t)
+	assert.NotEmpty(t, set.Id)
+	// name defaults to the code
+	assert.Equal(t, "WILD", set.Cards[2].Name)
+
+	registered, error := GetCardSet(set.Id)
+	assert.Nil(t, error)
+	assert.Equal(t, set, registered)
+	assert.Contains(t, CardSets(), set)
+
+	_, error = GetCardSet("unknown")
+	assert.ErrorIs(t, error, ErrCardSetNotFound)
+}
+
+func TestRegisterCardSetInvalid(t *testing.T) {
+	_, error := RegisterCardSet(CardSet{Name: "empty"})
+	assert.NotNil(t, error)
+	_, error = RegisterCardSet(CardSet{Cards: []CardDefinition{{Code: ""}}})
+	assert.NotNil(t, error)
+	_, error = RegisterCardSet(CardSet{Cards: []CardDefinition{{Code: "A,B"}}})
+	assert.NotNil(t, error)
+	_, error = RegisterCardSet(CardSet{Cards: []CardDefinition{{Code: "A"}, {Code: "A"}}})
+	assert.NotNil(t, error)
+
+	cards := make([]CardDefinition, MaxCardSetSize+1)
+	for i := range cards {
+		cards[i] = CardDefinition{Code: fmt.Sprint(i)}
+	}
+	_, error = RegisterCardSet(CardSet{Name: "large", Cards: cards})
+	assert.ErrorIs(t, error, ErrInvalidCardSet)
+	_, error = RegisterCardSet(CardSet{Name: "large", Cards: cards[:MaxCardSetSize]})
+	assert.Nil(t, error)
+}
+
+func TestRegisterCardSetLimit(t *testing.T) {
+	// the sets registered here are removed again, so that other tests can register theirs
+	registered := []string{}
+	defer func() {
+		cardSets.Lock()
+		for _, id := range registered {
+			delete(cardSets.sets, id)
+		}
+		cardSets.Unlock()
+	}()
+
+	cards := []CardDefinition{{Code: "X"}}
+	for len(CardSets()) < MaxCardSets {
+		set, error := RegisterCardSet(CardSet{Name: "filler", Cards: cards})
+		assert.Nil(t, error)
+		registered = append(registered, set.Id)
+	}
+	_, error := RegisterCardSet(CardSet{Name: "one too many", Cards: cards})
+	assert.ErrorIs(t, error, ErrInvalidCardSet)
+	assert.Equal(t, MaxCardSets, len(CardSets()))
+}
+
+func TestCreateDeckFromCardSet(t *testing.T) {
+	set := registerTestCardSet(t)
+
+	d, error := service.CreateDeck(DeckOptions{CardSet: set.Id})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"R1", "G1", "WILD"}, cardCodes(d.Cards))
+	assert.Equal(t, set.Id, d.CardSet)
+	assert.Empty(t, d.Type)
+	assert.True(t, d.Cards[0].IsCustom())
+	assert.Equal(t, "Red One", d.Cards[0].Name())
+	assert.Equal(t, "red", d.Cards[0].Custom.Attributes["color"])
+
+	// partial shoe of the set
+	d, error = service.CreateDeck(DeckOptions{CardSet: set.Id, Codes: "WILD, R1", DecksCount: 2})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"WILD", "R1", "WILD", "R1"}, cardCodes(d.Cards))
+
+	// codes of custom cards are case sensitive and must be part of the set
+	_, error = service.CreateDeck(DeckOptions{CardSet: set.Id, Codes: "r1"})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{CardSet: set.Id, Codes: "AS"})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{CardSet: set.Id, Type: PiquetDeck})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{CardSet: "unknown"})
+	assert.ErrorIs(t, error, ErrCardSetNotFound)
+}
+
+func TestCardSetFairShuffleVerifies(t *testing.T) {
+	set := registerTestCardSet(t)
+	d, error := service.CreateDeck(DeckOptions{CardSet: set.Id, Shuffle: true, DecksCount: 4, ClientSeed: "client",
+		ServerSeedHash: service.CommitServerSeed()})
+	assert.Nil(t, error)
+
+	order, error := VerifyShuffle(*d.Proof)
+	assert.Nil(t, error)
+	assert.Equal(t, cardCodes(d.Cards), order)
+}
+
+func TestCustomCardJSONRoundTrip(t *testing.T) {
+	set := registerTestCardSet(t)
+	card := set.newCards()[0]
+
+	data, error := json.Marshal(card)
+	assert.Nil(t, error)
+	assert.JSONEq(t, `{"value": "Red One", "suit": "", "code": "R1", "cardset": "`+set.Id+`",
+		"attributes": {"color": "red", "number": 1}}`, string(data))
+
+	var parsed Card
+	assert.Nil(t, json.Unmarshal(data, &parsed))
+	assert.True(t, card.Equal(parsed))
+	assert.Equal(t, "Red One", parsed.Name())
+	assert.False(t, parsed.Equal(NewCustomCard("other", CardDefinition{Code: "R1"})))
+}
@@ -0,0 +1,133 @@
+package deck
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+Tests in this file hammer a single deck from many goroutines.
+Run them with `go test -race` to also detect unsynchronized access.
+*/
+
+const drawingGoroutines = 500
+
+func TestConcurrentSingleCardDrawsDealEveryCardOnce(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	d, _ := s.CreateNewDeck(true, "")
+	deckId := d.DeckId.String()
+
+	hands := drawConcurrently(s, deckId, drawingGoroutines, 1)
+
+	// only 52 draws can succeed, all others find the deck empty
+	assertDealtExactlyOnce(t, d.Cards, hands)
+
+	deck, error := s.OpenDeck(deckId)
+	assert.Nil(t, error)
+	assert.Equal(t, 0, len(deck.Cards))
+}
+
+func TestConcurrentMultiCardDrawsDealEveryCardOnce(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	d, _ := s.CreateNewDeck(true, "")
+	deckId := d.DeckId.String()
+
+	// 52 is not a multiple of 3, so one card must remain in the deck
+	hands := drawConcurrently(s, deckId, drawingGoroutines, 3)
+	assert.Equal(t, 17, len(hands))
+
+	deck, _ := s.OpenDeck(deckId)
+	assert.Equal(t, 1, len(deck.Cards))
+	assertDealtExactlyOnce(t, d.Cards, append(hands, deck.Cards))
+}
+
+func TestConcurrentDrawsFromShoeDealEveryCardOnce(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	d, _ := s.CreateDeck(DeckOptions{Shuffle: true, DecksCount: 8})
+	deckId := d.DeckId.String()
+
+	// identical cards of the shoe must each be dealt once
+	hands := drawConcurrently(s, deckId, drawingGoroutines, 2)
+	assert.Equal(t, 208, len(hands))
+	assertDealtExactlyOnce(t, d.Cards, hands)
+}
+
+func TestConcurrentDrawsOnDifferentDecks(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	decks := []Deck{}
+	for i := 0; i < 10; i++ {
+		d, _ := s.CreateNewDeck(true, "")
+		decks = append(decks, d)
+	}
+
+	var wg sync.WaitGroup
+	results := make([][][]Card, len(decks))
+	for i, d := range decks {
+		wg.Add(1)
+		go func(i int, deckId string) {
+			defer wg.Done()
+			results[i] = drawConcurrently(s, deckId, 100, 2)
+		}(i, d.DeckId.String())
+	}
+	wg.Wait()
+
+	for i, d := range decks {
+		assertDealtExactlyOnce(t, d.Cards, results[i])
+	}
+}
+
+/*
+Draws count cards from the deck in as many goroutines as given and returns
+the hands of all successful draws
+*/
+func drawConcurrently(s *Service, deckId string, goroutines int, count int) [][]Card {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	hands := [][]Card{}
+
+	start := make(chan struct{})
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			hand, error := s.DrawCards(deckId, count)
+			if error != nil {
+				return
+			}
+			mu.Lock()
+			hands = append(hands, hand)
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return hands
+}
+
+/*
+Asserts that the dealt hands together contain every card of the deck exactly
+once. Cards which are in the deck more than once, as in a shoe, must be dealt
+as often as they are in the deck
+*/
+func assertDealtExactlyOnce(t *testing.T, deckCards []Card, hands [][]Card) {
+	dealt := map[string]int{}
+	total := 0
+	for _, hand := range hands {
+		for _, card := range hand {
+			dealt[card.Code()]++
+			total++
+		}
+	}
+	inDeck := map[string]int{}
+	for _, card := range deckCards {
+		inDeck[card.Code()]++
+	}
+
+	assert.Equal(t, len(deckCards), total)
+	for code, count := range inDeck {
+		assert.Equal(t, count, dealt[code], "card %v", code)
+	}
+}
@@ -0,0 +1,89 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// orders in which cards are dealt
+const (
+	// one card to each player in turn
+	DealRoundRobin = "round-robin"
+	// all cards of a hand to one player before the next player
+	DealBlock = "block"
+)
+
+/*
+Deals hands to players from the top of the deck in a single atomic operation.
+The hand of player i (starting at 1) is placed on pile "player<i>"
+inputs:
+	deckId    :  a UUID in string format
+	players   :  number of players
+	cardsEach :  number of cards dealt to each player
+	order     :  round-robin or block, round-robin if empty
+returns:
+	the hands in order of the players, cards in the order they were dealt
+	the updated deck
+	error if deck not found, is closed, has fewer cards or an input is invalid
+*/
+func (s *Service) Deal(deckId string, players int, cardsEach int, order string) ([][]Card, Deck, error) {
+	var hands [][]Card
+	if players <= 0 || cardsEach <= 0 {
+		return hands, Deck{}, newError(ErrInvalidArgument, "players and cards for each player must be more than zero")
+	}
+	order = strings.ToLower(strings.TrimSpace(order))
+	if len(order) == 0 {
+		order = DealRoundRobin
+	}
+	if order != DealRoundRobin && order != DealBlock {
+		message := fmt.Sprintf("deal order '%v' is invalid, should be %v or %v", order, DealRoundRobin, DealBlock)
+		return hands, Deck{}, newError(ErrInvalidArgument, message)
+	}
+
+	d, e := s.mutate(deckId, func(deck *Deck) error {
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot deal, deck is closed")
+		}
+		// checked before multiplying, so that huge inputs cannot overflow
+		if players > len(deck.Cards) || cardsEach > len(deck.Cards)/players {
+			message := fmt.Sprintf("cannot deal %d cards to each of %d players, deck has only %d",
+				cardsEach, players, len(deck.Cards))
+			return newError(ErrInsufficientCards, message)
+		}
+		count := players * cardsEach
+		dealt := cloneCards(deck.Cards[:count])
+		deck.Cards = deck.Cards[count:]
+		hands = splitHands(dealt, players, order)
+		for i, hand := range hands {
+			deck.placeOnPile(PlayerPile(i+1), hand)
+		}
+		deck.record(Event{Type: EventDeal, Cards: dealt, Players: players, Order: order})
+		return nil
+	})
+	if e != nil {
+		return nil, d, e
+	}
+	return hands, d, nil
+}
+
+// returns the name of the pile holding the hand of the player, players start at 1
+func PlayerPile(player int) string {
+	return fmt.Sprintf("player%d", player)
+}
+
+// splits the dealt cards into hands of equal size in the given order
+func splitHands(dealt []Card, players int, order string) [][]Card {
+	cardsEach := len(dealt) / players
+	hands := make([][]Card, players)
+	for i := range hands {
+		hands[i] = make([]Card, 0, cardsEach)
+	}
+	for i, card := range dealt {
+		player := i % players
+		if order == DealBlock {
+			player = i / cardsEach
+		}
+		hands[player] = append(hands[player], card)
+	}
+	return hands
+}
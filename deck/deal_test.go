@@ -0,0 +1,66 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDealRoundRobin(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "")
+	deck_id := d.DeckId.String()
+
+	hands, deck, error := service.Deal(deck_id, 4, 5, "")
+	assert.Nil(t, error)
+	assert.Equal(t, 4, len(hands))
+	assert.Equal(t, []string{"AS", "5S", "9S", "KS", "4D"}, cardCodes(hands[0]))
+	assert.Equal(t, []string{"4S", "8S", "QS", "3D", "7D"}, cardCodes(hands[3]))
+	assert.Equal(t, 32, len(deck.Cards))
+	assert.Equal(t, hands[0], deck.Piles["player1"])
+	assert.Equal(t, hands[3], deck.Piles[PlayerPile(4)])
+
+	last := deck.History[len(deck.History)-1]
+	assert.Equal(t, EventDeal, last.Type)
+	assert.Equal(t, 20, len(last.Cards))
+	assert.Equal(t, DealRoundRobin, last.Order)
+}
+
+func TestDealBlock(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,2S,3S,4S,5S,6S,7S")
+	deck_id := d.DeckId.String()
+
+	hands, deck, error := service.Deal(deck_id, 2, 3, DealBlock)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "2S", "3S"}, cardCodes(hands[0]))
+	assert.Equal(t, []string{"4S", "5S", "6S"}, cardCodes(hands[1]))
+	assert.Equal(t, []string{"7S"}, cardCodes(deck.Cards))
+}
+
+func TestDealIsAtomic(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,2S,3S,4S,5S")
+	deck_id := d.DeckId.String()
+
+	// not enough cards for every player, nothing is dealt
+	_, _, error := service.Deal(deck_id, 3, 2, "")
+	assert.NotNil(t, error)
+	deck, _ := service.OpenDeck(deck_id)
+	assert.Equal(t, 5, len(deck.Cards))
+	assert.Empty(t, deck.Piles)
+
+	_, _, error = service.Deal(deck_id, 0, 2, "")
+	assert.NotNil(t, error)
+	_, _, error = service.Deal(deck_id, 2, 0, "")
+	assert.NotNil(t, error)
+	_, _, error = service.Deal(deck_id, 2, 2, "clockwise")
+	assert.NotNil(t, error)
+
+	// the number of cards dealt would overflow
+	_, _, error = service.Deal(deck_id, 3037000500, 3037000500, "")
+	assert.ErrorIs(t, error, ErrInsufficientCards)
+	_, _, error = service.Deal(deck_id, 1<<62+1, 4, "")
+	assert.ErrorIs(t, error, ErrInsufficientCards)
+	_, _, error = service.Deal(deck_id, 4, 1<<62+1, "")
+	assert.ErrorIs(t, error, ErrInsufficientCards)
+	deck, _ = service.OpenDeck(deck_id)
+	assert.Equal(t, 5, len(deck.Cards))
+}
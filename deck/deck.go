@@ -5,56 +5,108 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// type to represent a single Card
-type Card struct {
-	Value string `json:"value"`
-	Suit  string `json:"suit"`
-	Code  string `json:"code"`
-}
-
 // type to represent a Deck of cards
 type Deck struct {
-	DeckId   uuid.UUID
-	Cards    []Card
-	Shuffled bool
+	DeckId    uuid.UUID
+	Cards     []Card
+	Shuffled  bool
+	CreatedAt time.Time
+	// name of the template the deck is made from, see Template
+	Type string `json:",omitempty"`
+	// id of the custom card set the deck is made from, see RegisterCardSet
+	CardSet string `json:",omitempty"`
+	// incremented by the store on every update, used for compare-and-swap
+	Version uint64
+	// shuffle method and passes, empty if deck is not shuffled
+	ShuffleMethod string `json:",omitempty"`
+	ShufflePasses int    `json:",omitempty"`
+	// seed of the shuffle, nil if deck is not shuffled or shuffled without seed
+	Seed *int64 `json:",omitempty"`
+	// proof of a provably fair shuffle, set when deck is shuffled without seed
+	Proof *ShuffleProof `json:",omitempty"`
+	// a closed deck cannot be drawn from and its server seed can be revealed
+	Closed bool `json:",omitempty"`
+	// number of copies of the base deck in a shoe, 1 for a single deck
+	DecksCount int `json:",omitempty"`
+	// number of cards in the deck when it was created
+	Size int `json:",omitempty"`
+	// number of dealt cards after which the shoe must be reshuffled, no cut card if 0
+	CutCard int `json:",omitempty"`
+	// cards drawn from the deck or a pile which are not in any pile
+	Drawn []Card `json:",omitempty"`
+	// named piles of cards, e.g. discard or player1, top card first
+	Piles map[string][]Card `json:",omitempty"`
+	// every operation on the deck, oldest first
+	History []Event `json:",omitempty"`
+	// named snapshots of the deck which can be restored, see SaveSnapshot
+	Snapshots map[string]Snapshot `json:",omitempty"`
 }
 
-// list of suits and values
-var cardSuits = []string{"SPADES", "DIMONDS", "CLUBS", "HEARTS"}
-var cardValues = []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
-
-// map to get suit name from initial letter
-var suitNames = map[string]string{
-	"S": "SPADES",
-	"D": "DIMONDS",
-	"C": "CLUBS",
-	"H": "HEARTS",
+// type to create, open and draw from decks kept in a DeckStore
+type Service struct {
+	store DeckStore
+	// generator for server seeds of shuffles without seed, guarded by randomMu
+	random   *rand.Rand
+	randomMu sync.Mutex
+	// committed server seeds by their hash, oldest first, guarded by seedsMu. See CommitServerSeed
+	pendingSeeds     map[string]string
+	pendingSeedOrder []string
+	seedsMu          sync.Mutex
 }
 
-// map to get card value from initial letter
-var valueNames = map[string]string{
-	"A":  "ACE",
-	"2":  "2",
-	"3":  "3",
-	"4":  "4",
-	"5":  "5",
-	"6":  "6",
-	"7":  "7",
-	"8":  "8",
-	"9":  "9",
-	"10": "10",
-	"J":  "JACK",
-	"Q":  "QUEEN",
-	"K":  "KING",
+// options to create a new deck
+type DeckOptions struct {
+	// whether deck is shuffled or not
+	Shuffle bool
+	// comma separated list of card codes, all cards of the template are added if empty
+	Codes string
+	// name of the deck template, standard 52 card deck if empty. See Template
+	Type string
+	// number of jokers added to a deck with all cards of the template
+	Jokers int
+	// shuffle method and passes used when Shuffle is true, see NewShuffler
+	ShuffleMethod string
+	ShufflePasses int
+	// makes the shuffle reproducible, the shuffle is provably fair if nil
+	Seed *int64
+	// client seed of a provably fair shuffle, ignored if Seed is set
+	ClientSeed string
+	// hash of a server seed from CommitServerSeed, required with ClientSeed
+	ServerSeedHash string
+	// number of copies of the base deck in a shoe, a single deck if 0
+	DecksCount int
+	// fraction of the shoe dealt before the cut card is reached, no cut card if 0
+	Penetration float64
+	// id of a custom card set the deck is made from instead of a template
+	CardSet string
 }
 
-// stores map of uuid and deck
-var generatedDecks = map[uuid.UUID]Deck{}
+// maximum number of decks in a shoe
+const MaxDecksCount = 16
+
+// maximum number of jokers added to a deck
+const MaxJokers = 8
+
+/*
+Returns a new Service which keeps its decks in the given store.
+Shuffles use crypto/rand unless another source is given as option
+*/
+func NewService(store DeckStore, options ...ServiceOption) *Service {
+	s := &Service{
+		store:  store,
+		random: rand.New(CryptoSource{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
 
 /*
 Creates a new deck of cards based on input arguments
@@ -66,23 +118,69 @@ returns:
 	a newly created deck
 	error if any card code is invalid
 */
-func CreateNewDeck(shuffle bool, codes string) (Deck, error) {
+func (s *Service) CreateNewDeck(shuffle bool, codes string) (Deck, error) {
+	return s.CreateDeck(DeckOptions{Shuffle: shuffle, Codes: codes})
+}
+
+/*
+Creates a new deck of cards based on given options
+inputs:
+	options :  see DeckOptions
+returns:
+	a newly created deck
+	error if any card code is invalid
+*/
+func (s *Service) CreateDeck(options DeckOptions) (Deck, error) {
 	var d Deck
 	var e error
-	if len(codes) == 0 {
-		d = newSequentialDeck()
+	decksCount := options.DecksCount
+	if decksCount == 0 {
+		decksCount = 1
+	}
+	if decksCount < 0 || decksCount > MaxDecksCount {
+		message := fmt.Sprintf("decks count %d is invalid, should be between 1 and %d", options.DecksCount, MaxDecksCount)
+		return d, newError(ErrInvalidArgument, message)
+	}
+	if !(options.Penetration >= 0 && options.Penetration <= 1) {
+		message := fmt.Sprintf("penetration %v is invalid, should be between 0 and 1", options.Penetration)
+		return d, newError(ErrInvalidArgument, message)
+	}
+
+	if len(options.CardSet) > 0 {
+		d.Cards, e = newCardSetCards(options)
+		d.CardSet = options.CardSet
 	} else {
-		d, e = newDeckFromCodes(codes)
+		d.Cards, e = newTemplateCards(options)
+		d.Type = normalizeTemplateName(options.Type)
+	}
+	if e != nil {
+		return d, e
+	}
+	d.Cards = newShoe(d.Cards, decksCount)
+	d.DecksCount = decksCount
+	d.Size = len(d.Cards)
+	d.CutCard = cutCardPosition(d.Size, options.Penetration)
+	d.record(Event{Type: EventCreate, Cards: d.Cards})
+	if options.Shuffle {
+		d.ShuffleMethod = normalizeShuffleMethod(options.ShuffleMethod)
+		d.ShufflePasses = options.ShufflePasses
+		if options.Seed != nil {
+			e = seededShuffle(d.Cards, d.ShuffleMethod, d.ShufflePasses, *options.Seed)
+			seed := *options.Seed
+			d.Seed = &seed
+		} else {
+			d.Proof, e = s.fairShuffle(d.Cards, d.ShuffleMethod, d.ShufflePasses, options.ClientSeed, options.ServerSeedHash)
+		}
 		if e != nil {
 			return d, e
 		}
-	}
-	if shuffle {
-		d.shuffle()
+		d.record(Event{Type: EventShuffle, Cards: d.Cards, ShuffleMethod: d.ShuffleMethod,
+			ShufflePasses: d.ShufflePasses, Seed: d.Seed})
 	}
 	d.DeckId = uuid.New()
-	d.Shuffled = shuffle
-	generatedDecks[d.DeckId] = d
+	d.Shuffled = options.Shuffle
+	d.CreatedAt = time.Now().UTC()
+	e = s.store.Put(d)
 	return d, e
 }
 
@@ -94,19 +192,48 @@ returns:
 	an existing deck with given UUID
 	error if UUID is not valid or deck not found
 */
-func OpenDeck(deckId string) (Deck, error) {
+func (s *Service) OpenDeck(deckId string) (Deck, error) {
 	var d Deck
 	uuid, error := parseUUID(deckId)
 	if error != nil {
 		return d, error
 	}
 
-	if !checkUUIDExists(uuid) {
-		message := fmt.Sprintf("deck not found for the input uuid %v", deckId)
-		return d, errors.New(message)
+	d, error = s.store.Get(uuid)
+	if errors.Is(error, ErrDeckNotFound) {
+		return d, fmt.Errorf("%w for the input uuid %v", ErrDeckNotFound, deckId)
 	}
 
-	return generatedDecks[uuid], nil
+	return d, error
+}
+
+/*
+Deletes an existing deck
+inputs:
+	deckId :  a UUID in string format
+returns:
+	error if UUID is not valid or deck not found
+*/
+func (s *Service) DeleteDeck(deckId string) error {
+	uuid, error := parseUUID(deckId)
+	if error != nil {
+		return error
+	}
+	error = s.store.Delete(uuid)
+	if errors.Is(error, ErrDeckNotFound) {
+		return fmt.Errorf("%w for the input uuid %v", ErrDeckNotFound, deckId)
+	}
+	return error
+}
+
+/*
+Returns all decks, oldest first
+returns:
+	the decks
+	error if the decks cannot be read from the store
+*/
+func (s *Service) ListDecks() ([]Deck, error) {
+	return s.store.List()
 }
 
 /*
@@ -118,32 +245,102 @@ returns:
 	a slice of cards representing a hand
 	error if UUID is not valid or deck not found or sufficient cards not available
 */
-func DrawCards(deckId string, count int) ([]Card, error) {
-	var cards []Card
+func (s *Service) DrawCards(deckId string, count int) ([]Card, error) {
+	hand, _, error := s.Draw(deckId, DrawOptions{Count: count})
+	return hand, error
+}
 
-	if count <= 0 {
-		return cards, errors.New("count must be more than zero")
+// returns the number of cards dealt from the deck since it was created
+func (d Deck) Dealt() int {
+	if d.Size < len(d.Cards) {
+		return 0
 	}
+	return d.Size - len(d.Cards)
+}
 
-	deck, error := OpenDeck(deckId)
-	if error != nil {
-		return cards, error
+// returns true once the cut card of a shoe is reached and it must be reshuffled
+func (d Deck) NeedsReshuffle() bool {
+	return d.CutCard > 0 && d.Dealt() >= d.CutCard
+}
+
+// returns the depth of a shoe, i.e. the number of decks which remain in it
+func (d Deck) RemainingDecks() float64 {
+	if d.Size == 0 || d.DecksCount == 0 {
+		return 0
 	}
+	return float64(len(d.Cards)) * float64(d.DecksCount) / float64(d.Size)
+}
 
-	cards = deck.Cards
+/*
+Closes the deck, no more cards can be drawn from a closed deck
+inputs:
+	deckId :  a UUID in string format
+returns:
+	the closed deck
+	error if UUID is not valid or deck not found
+*/
+func (s *Service) CloseDeck(deckId string) (Deck, error) {
+	return s.mutate(deckId, func(deck *Deck) error {
+		deck.Closed = true
+		deck.record(Event{Type: EventClose})
+		return nil
+	})
+}
 
-	if len(cards) == 0 {
-		return cards, errors.New("cannot draw any cards, deck is empty")
+/*
+Returns the proof of the shuffle of a deck including its server seed.
+The server seed is only revealed once no more cards can be drawn.
+inputs:
+	deckId :  a UUID in string format
+returns:
+	proof which can be checked with VerifyShuffle
+	error if deck not found, was not shuffled with a server seed or
+	is neither exhausted nor closed
+*/
+func (s *Service) RevealSeed(deckId string) (ShuffleProof, error) {
+	deck, error := s.OpenDeck(deckId)
+	if error != nil {
+		return ShuffleProof{}, error
 	}
+	if deck.Proof == nil {
+		return ShuffleProof{}, newError(ErrSeedNotRevealed, "deck was not shuffled with a server seed")
+	}
+	if !deck.Closed && len(deck.Cards) > 0 {
+		return ShuffleProof{}, newError(ErrSeedNotRevealed, "server seed is revealed only once deck is exhausted or closed")
+	}
+	return *deck.Proof, nil
+}
+
+/*
+Applies change to the deck with given id and stores the result.
+If another operation updates the deck in between, the deck is read again and
+change is re-applied, so that concurrent mutations of a deck are linearizable.
+Every operation which modifies a stored deck must go through this method.
+inputs:
+	deckId :  a UUID in string format
+	change :  modifies the deck, returning an error aborts the mutation
+returns:
+	the updated deck
+	error if deck cannot be opened, change fails or store fails
+*/
+func (s *Service) mutate(deckId string, change func(deck *Deck) error) (Deck, error) {
+	for {
+		deck, error := s.OpenDeck(deckId)
+		if error != nil {
+			return deck, error
+		}
 
-	if count > len(cards) {
-		message := fmt.Sprintf("cannot draw %d cards, deck has only %d", count, len(cards))
-		return cards, errors.New(message)
+		error = change(&deck)
+		if error != nil {
+			return deck, error
+		}
+
+		updated, error := s.store.Update(deck)
+		if errors.Is(error, ErrVersionConflict) {
+			continue
+		}
+		return updated, error
 	}
-	hand, cards := deck.Cards[:count], deck.Cards[count:]
-	deck.Cards = cards
-	generatedDecks[deck.DeckId] = deck
-	return hand, error
 }
 
 /*
@@ -156,25 +353,57 @@ func (d Deck) Print() {
 	}
 }
 
+// returns the cards of a new deck made from the template in the options
+func newTemplateCards(options DeckOptions) ([]Card, error) {
+	template, e := Template(options.Type)
+	if e != nil {
+		return nil, e
+	}
+	if options.Jokers < 0 || options.Jokers > MaxJokers {
+		message := fmt.Sprintf("jokers %d is invalid, should be between 0 and %d", options.Jokers, MaxJokers)
+		return nil, newError(ErrInvalidArgument, message)
+	}
+	if options.Jokers > 0 && !template.AllowsJokers {
+		message := fmt.Sprintf("%d jokers cannot be added to a %v deck", options.Jokers, template.Name)
+		return nil, newError(ErrInvalidArgument, message)
+	}
+	if len(options.Codes) == 0 {
+		return template.Cards(options.Jokers), nil
+	}
+	d, e := newDeckFromCodes(options.Codes, template)
+	return d.Cards, e
+}
+
+// returns the cards of a new deck made from the custom card set in the options
+func newCardSetCards(options DeckOptions) ([]Card, error) {
+	if len(options.Type) > 0 || options.Jokers != 0 {
+		return nil, newError(ErrInvalidArgument, "type and jokers cannot be used with a custom card set")
+	}
+	set, e := GetCardSet(options.CardSet)
+	if e != nil {
+		return nil, e
+	}
+	if len(options.Codes) == 0 {
+		return set.newCards(), nil
+	}
+	return set.cardsFromCodes(options.Codes)
+}
+
 /*
 Creates and returns a deck of cards initilized with incoming card codes
-Returns error if any code is invalid
+Returns error if any code is invalid or not part of the template
 */
-func newDeckFromCodes(codes string) (Deck, error) {
+func newDeckFromCodes(codes string, template DeckTemplate) (Deck, error) {
 	var d Deck
 	deckCards := []Card{}
 	codeList := strings.Split(codes, ",")
 	for _, code := range codeList {
-		code = strings.TrimSpace(code)
-		error := validateCardCode(code)
-
+		error := validateCardCode(code, template)
 		if error != nil {
 			return d, error
 		}
-		l := len(code)
-		c := Card{Value: valueNames[code[:l-1]], Suit: suitNames[code[l-1:]], Code: code}
+		c, _ := ParseCard(code)
 		deckCards = append(deckCards, c)
-
 	}
 	d.Cards = deckCards
 	return d, nil
@@ -185,29 +414,42 @@ Valdates card code and returns error if any check fails
 Code is valid iff none of below fails:
 1. has a valid suit
 2. has a valid value
+3. the card is part of the deck template
 */
-func validateCardCode(code string) error {
-	code = strings.TrimSpace(code)
-	if len(code) < 2 {
-		message := fmt.Sprintf("code %v is invalid", code)
-		return errors.New(message)
+func validateCardCode(code string, template DeckTemplate) error {
+	card, error := ParseCard(code)
+	if error != nil {
+		return error
 	}
-
-	suit := code[len(code)-1:]
-	_, suitExists := suitNames[suit]
-	if !suitExists {
-		message := fmt.Sprintf("code %v is invalid, should have proper suit name", code)
-		return errors.New(message)
+	if !template.Contains(card) {
+		message := fmt.Sprintf("code %v is invalid, card is not part of a %v deck", strings.TrimSpace(code), template.Name)
+		return newError(ErrInvalidCard, message)
 	}
+	return nil
+}
 
-	value := code[:len(code)-1]
-	_, valueExists := valueNames[value]
-	if !valueExists {
-		message := fmt.Sprintf("code %v is invalid, should have proper card value", code)
-		return errors.New(message)
+/*
+Returns a shoe made of the given number of copies of the cards, one copy
+after the other. Identical cards of a shoe are told apart only by position
+*/
+func newShoe(cards []Card, decksCount int) []Card {
+	shoe := make([]Card, 0, len(cards)*decksCount)
+	for i := 0; i < decksCount; i++ {
+		shoe = append(shoe, cards...)
 	}
+	return shoe
+}
 
-	return nil
+// returns the number of cards dealt before the cut card, 0 if there is no cut card
+func cutCardPosition(size int, penetration float64) int {
+	if penetration == 0 || size == 0 {
+		return 0
+	}
+	position := int(float64(size) * penetration)
+	if position < 1 {
+		position = 1
+	}
+	return position
 }
 
 /*
@@ -215,35 +457,57 @@ Creates and returns a deck of 52 cards in sequential order
 */
 func newSequentialDeck() Deck {
 	var d Deck
-	deckCards := []Card{}
-
-	for _, suit := range cardSuits {
-		for _, value := range cardValues {
-			code := value[:1] + suit[:1]
-			deckCards = append(deckCards, Card{valueNames[value], suit, code})
-		}
-	}
-	d.Cards = deckCards
+	d.Cards = deckTemplates[StandardDeck].Cards(0)
 	return d
 }
 
 /*
-Shuffles the cards in the receiver deck
+Shuffles the cards in place with given method, the order only depends on the seed
 */
-func (d Deck) shuffle() {
-	rand.Seed(time.Now().UnixNano())
-	for i := range d.Cards {
-		pos := rand.Intn(len(d.Cards))
-		d.Cards[i], d.Cards[pos] = d.Cards[pos], d.Cards[i]
+func seededShuffle(cards []Card, method string, passes int, seed int64) error {
+	shuffler, e := NewShuffler(method, passes)
+	if e != nil {
+		return e
 	}
+	shuffler.Shuffle(cards, seededRand(seed))
+	return nil
 }
 
 /*
-Returns true if UUID exists in the map of generated decks, false otherwise
+Returns a copy of the deck which does not share its cards and piles with the receiver
 */
-func checkUUIDExists(uuid uuid.UUID) bool {
-	_, exists := generatedDecks[uuid]
-	return exists
+func (d Deck) clone() Deck {
+	d.Cards = cloneCards(d.Cards)
+	if d.Drawn != nil {
+		d.Drawn = cloneCards(d.Drawn)
+	}
+	if d.History != nil {
+		history := make([]Event, len(d.History))
+		copy(history, d.History)
+		d.History = history
+	}
+	if d.Snapshots != nil {
+		snapshots := make(map[string]Snapshot, len(d.Snapshots))
+		for name, snapshot := range d.Snapshots {
+			snapshots[name] = snapshot.clone()
+		}
+		d.Snapshots = snapshots
+	}
+	if d.Piles != nil {
+		piles := make(map[string][]Card, len(d.Piles))
+		for name, cards := range d.Piles {
+			piles[name] = cloneCards(cards)
+		}
+		d.Piles = piles
+	}
+	return d
+}
+
+// returns a copy of the cards, never nil
+func cloneCards(cards []Card) []Card {
+	cloned := make([]Card, len(cards))
+	copy(cloned, cards)
+	return cloned
 }
 
 /*
@@ -253,7 +517,7 @@ func parseUUID(uuidStr string) (uuid.UUID, error) {
 	uuid, error := uuid.Parse(uuidStr)
 	if error != nil {
 		message := fmt.Sprintf("input UUID '%v' is not a valid UUID4 value", uuidStr)
-		return uuid, errors.New(message)
+		return uuid, newError(ErrInvalidDeckId, message)
 	}
 	return uuid, nil
 }
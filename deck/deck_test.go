@@ -1,15 +1,21 @@
 package deck
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
+// service used by the tests, backed by an in-memory store
+var service = NewService(NewMemoryStore())
+
 func TestNewSequentialFullDeck(t *testing.T) {
 	// create new full sequential deck
-	deck, error := CreateNewDeck(false, "")
+	deck, error := service.CreateNewDeck(false, "")
 
 	// assert no errors and deck is returned
 	assert.Nil(t, error)
@@ -19,14 +25,14 @@ func TestNewSequentialFullDeck(t *testing.T) {
 	assert.Equal(t, 52, len(deck.Cards))
 
 	// assert first and last cards (for sequence)
-	assert.Equal(t, "AS", deck.Cards[0].Code)
-	assert.Equal(t, "KH", deck.Cards[len(deck.Cards)-1].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
+	assert.Equal(t, "KH", deck.Cards[len(deck.Cards)-1].Code())
 
 }
 
 func TestNewShuffledFullDeck(t *testing.T) {
 	// create new full sequential deck
-	deck, error := CreateNewDeck(true, "")
+	deck, error := service.CreateNewDeck(true, "")
 
 	// assert no errors and deck is returned
 	assert.Nil(t, error)
@@ -39,7 +45,7 @@ func TestNewShuffledFullDeck(t *testing.T) {
 func TestNewSequentialPartialDeckValid(t *testing.T) {
 	// create new deck from codes
 	codes := "AS,KD,AC,2C,KH,10H"
-	deck, error := CreateNewDeck(false, codes)
+	deck, error := service.CreateNewDeck(false, codes)
 
 	// assert no errors and deck is returned
 	assert.Nil(t, error)
@@ -49,8 +55,8 @@ func TestNewSequentialPartialDeckValid(t *testing.T) {
 	assert.Equal(t, 6, len(deck.Cards))
 
 	// assert first and last cards (for sequence)
-	assert.Equal(t, "AS", deck.Cards[0].Code)
-	assert.Equal(t, "10H", deck.Cards[len(deck.Cards)-1].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
+	assert.Equal(t, "10H", deck.Cards[len(deck.Cards)-1].Code())
 
 }
 
@@ -61,27 +67,58 @@ func TestNewPartialDeckVInvalid(t *testing.T) {
 		"2C KH 10H",
 		"D1",
 		"  ",
-		"aS",
-		"As",
-		"as",
+		"1S,KD",
+		"AS,,KD",
 	}
 
 	for _, codes := range codesList {
-		_, error := CreateNewDeck(true, codes)
+		_, error := service.CreateNewDeck(true, codes)
 		assert.NotNil(t, error)
-		_, error = CreateNewDeck(false, codes)
+		_, error = service.CreateNewDeck(false, codes)
 		assert.NotNil(t, error)
 	}
 
 }
 
+func TestNewPartialDeckCaseInsensitiveCodes(t *testing.T) {
+	deck, error := service.CreateNewDeck(false, "aS,As,as,10h,td,k♠")
+	assert.Nil(t, error)
+
+	// all codes are normalized to canonical codes
+	codes := []string{}
+	for _, card := range deck.Cards {
+		codes = append(codes, card.Code())
+	}
+	assert.Equal(t, []string{"AS", "AS", "AS", "10H", "10D", "KS"}, codes)
+}
+
+func TestNewSequentialDeckTensRoundTrip(t *testing.T) {
+	deck, _ := service.CreateNewDeck(false, "")
+	tens := []string{}
+	for _, card := range deck.Cards {
+		if card.Rank == Ten {
+			tens = append(tens, card.Code())
+		}
+	}
+	assert.Equal(t, []string{"10S", "10D", "10C", "10H"}, tens)
+
+	// codes of a full deck can be used to create a deck
+	codes := []string{}
+	for _, card := range deck.Cards {
+		codes = append(codes, card.Code())
+	}
+	copied, error := service.CreateNewDeck(false, strings.Join(codes, ","))
+	assert.Nil(t, error)
+	assert.Equal(t, deck.Cards, copied.Cards)
+}
+
 func TestOpenDeckValid(t *testing.T) {
 	// create new full sequential deck
-	d, _ := CreateNewDeck(false, "")
+	d, _ := service.CreateNewDeck(false, "")
 	deck_id := d.DeckId.String()
 
 	// open deck
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 
 	// assert no errors and deck is returned
 	assert.Nil(t, error)
@@ -91,19 +128,19 @@ func TestOpenDeckValid(t *testing.T) {
 	assert.Equal(t, 52, len(deck.Cards))
 
 	// assert first and last cards (for sequence)
-	assert.Equal(t, "AS", deck.Cards[0].Code)
-	assert.Equal(t, "KH", deck.Cards[len(deck.Cards)-1].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
+	assert.Equal(t, "KH", deck.Cards[len(deck.Cards)-1].Code())
 
 }
 
 func TestNOpenPartialDeckValid(t *testing.T) {
 	// create new deck from codes
 	codes := "AS,KD,AC,2C,KH,10H"
-	d, _ := CreateNewDeck(false, codes)
+	d, _ := service.CreateNewDeck(false, codes)
 	deck_id := d.DeckId.String()
 
 	// open deck
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 
 	// assert no errors and deck is returned
 	assert.Nil(t, error)
@@ -113,76 +150,108 @@ func TestNOpenPartialDeckValid(t *testing.T) {
 	assert.Equal(t, 6, len(deck.Cards))
 
 	// assert first and last cards (for sequence)
-	assert.Equal(t, "AS", deck.Cards[0].Code)
-	assert.Equal(t, "10H", deck.Cards[len(deck.Cards)-1].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
+	assert.Equal(t, "10H", deck.Cards[len(deck.Cards)-1].Code())
 
 }
 
 func TestOpenDeckInvalidUUID(t *testing.T) {
-	_, error := OpenDeck("1234")
+	_, error := service.OpenDeck("1234")
 	assert.NotNil(t, error)
 }
 
 func TestOpenDeckUnknownUUID(t *testing.T) {
-	_, error := OpenDeck(uuid.New().String())
-	assert.NotNil(t, error)
+	id := uuid.New().String()
+	_, error := service.OpenDeck(id)
+	assert.True(t, errors.Is(error, ErrDeckNotFound))
+	assert.Equal(t, "deck not found for the input uuid "+id, error.Error())
+}
+
+func TestDeleteDeck(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "")
+	deck_id := d.DeckId.String()
+
+	assert.Nil(t, service.DeleteDeck(deck_id))
+	_, error := service.OpenDeck(deck_id)
+	assert.True(t, errors.Is(error, ErrDeckNotFound))
+	assert.True(t, errors.Is(service.DeleteDeck(deck_id), ErrDeckNotFound))
+	assert.NotNil(t, service.DeleteDeck("1234"))
+}
+
+func TestListDecks(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	decks, error := s.ListDecks()
+	assert.Nil(t, error)
+	assert.Empty(t, decks)
+
+	first, _ := s.CreateNewDeck(false, "AS")
+	second, _ := s.CreateNewDeck(false, "KD")
+	decks, error = s.ListDecks()
+	assert.Nil(t, error)
+	assert.Equal(t, 2, len(decks))
+	assert.Equal(t, first.DeckId, decks[0].DeckId)
+	assert.Equal(t, second.DeckId, decks[1].DeckId)
+
+	s.DeleteDeck(first.DeckId.String())
+	decks, _ = s.ListDecks()
+	assert.Equal(t, 1, len(decks))
 }
 
 func TestDrawCardInvalidUUID(t *testing.T) {
-	_, error := DrawCards("1234", 4)
+	_, error := service.DrawCards("1234", 4)
 	assert.NotNil(t, error)
 
-	_, error = DrawCards(uuid.New().String(), 6)
+	_, error = service.DrawCards(uuid.New().String(), 6)
 	assert.NotNil(t, error)
 }
 
 func TestDrawCardsSuccess(t *testing.T) {
 	// new deck from codes
 	codes := "AS,KD,AC,2C,KH,10H"
-	d, _ := CreateNewDeck(false, codes)
+	d, _ := service.CreateNewDeck(false, codes)
 	deck_id := d.DeckId.String()
 
 	// open deck
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 6, len(deck.Cards))
-	assert.Equal(t, "AS", deck.Cards[0].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
 
 	// draw cards
-	cards, error := DrawCards(deck_id, 2)
+	cards, error := service.DrawCards(deck_id, 2)
 	assert.Nil(t, error)
 	assert.Equal(t, 2, len(cards))
-	assert.Equal(t, "AS", cards[0].Code)
-	assert.Equal(t, "KD", cards[1].Code)
+	assert.Equal(t, "AS", cards[0].Code())
+	assert.Equal(t, "KD", cards[1].Code())
 
 	// open deck to verify remaining cards
-	deck, error = OpenDeck(deck_id)
+	deck, error = service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 4, len(deck.Cards))
-	assert.Equal(t, "AC", deck.Cards[0].Code)
+	assert.Equal(t, "AC", deck.Cards[0].Code())
 
 	// draw some more cards
-	cards, error = DrawCards(deck_id, 3)
+	cards, error = service.DrawCards(deck_id, 3)
 	assert.Nil(t, error)
 	assert.Equal(t, 3, len(cards))
-	assert.Equal(t, "AC", cards[0].Code)
-	assert.Equal(t, "2C", cards[1].Code)
-	assert.Equal(t, "KH", cards[2].Code)
+	assert.Equal(t, "AC", cards[0].Code())
+	assert.Equal(t, "2C", cards[1].Code())
+	assert.Equal(t, "KH", cards[2].Code())
 
 	// open deck to verify remaining cards
-	deck, error = OpenDeck(deck_id)
+	deck, error = service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 1, len(deck.Cards))
-	assert.Equal(t, "10H", deck.Cards[0].Code)
+	assert.Equal(t, "10H", deck.Cards[0].Code())
 
 	// draw remaining cards
-	cards, error = DrawCards(deck_id, 1)
+	cards, error = service.DrawCards(deck_id, 1)
 	assert.Nil(t, error)
 	assert.Equal(t, 1, len(cards))
-	assert.Equal(t, "10H", cards[0].Code)
+	assert.Equal(t, "10H", cards[0].Code())
 
 	// open deck to verify no remaining cards
-	deck, error = OpenDeck(deck_id)
+	deck, error = service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 0, len(deck.Cards))
 }
@@ -190,71 +259,145 @@ func TestDrawCardsSuccess(t *testing.T) {
 func TestDrawCardsCountZero(t *testing.T) {
 	// new deck from codes
 	codes := "AS,KD,AC,2C,KH,10H"
-	d, _ := CreateNewDeck(false, codes)
+	d, _ := service.CreateNewDeck(false, codes)
 	deck_id := d.DeckId.String()
 
 	// open deck
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 6, len(deck.Cards))
-	assert.Equal(t, "AS", deck.Cards[0].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
 
 	// cannot draw zero cards
-	_, error = DrawCards(deck_id, 0)
+	_, error = service.DrawCards(deck_id, 0)
 	assert.NotNil(t, error)
 }
 
 func TestDrawCardsNoCardsLeft(t *testing.T) {
 	codes := "AS,KD,AC,2C,KH,10H"
-	d, _ := CreateNewDeck(false, codes)
+	d, _ := service.CreateNewDeck(false, codes)
 	deck_id := d.DeckId.String()
 
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 6, len(deck.Cards))
-	assert.Equal(t, "AS", deck.Cards[0].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
 
-	cards, error := DrawCards(deck_id, 6)
+	cards, error := service.DrawCards(deck_id, 6)
 	assert.Nil(t, error)
 	assert.Equal(t, 6, len(cards))
 
-	deck, error = OpenDeck(deck_id)
+	deck, error = service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 0, len(deck.Cards))
 
-	_, error = DrawCards(deck_id, 1)
+	_, error = service.DrawCards(deck_id, 1)
 	assert.NotNil(t, error)
 }
 
 func TestDrawCardsCountMoreThanCards(t *testing.T) {
 	codes := "AS,KD,AC,2C,KH,10H"
-	d, _ := CreateNewDeck(false, codes)
+	d, _ := service.CreateNewDeck(false, codes)
 	deck_id := d.DeckId.String()
 
-	deck, error := OpenDeck(deck_id)
+	deck, error := service.OpenDeck(deck_id)
 	assert.Nil(t, error)
 	assert.Equal(t, 6, len(deck.Cards))
-	assert.Equal(t, "AS", deck.Cards[0].Code)
+	assert.Equal(t, "AS", deck.Cards[0].Code())
 
-	_, error = DrawCards(deck_id, 10)
+	_, error = service.DrawCards(deck_id, 10)
 	assert.NotNil(t, error)
 }
 
 func TestValidateCardCodes(t *testing.T) {
+	standard, _ := Template(StandardDeck)
 
 	// validate different card codes
 
-	assert.Nil(t, validateCardCode("AH"))
-	assert.Nil(t, validateCardCode("KC"))
-	assert.Nil(t, validateCardCode("JD"))
-	assert.Nil(t, validateCardCode("QS"))
-	assert.Nil(t, validateCardCode("10D"))
-	assert.Nil(t, validateCardCode("4C"))
-	assert.NotNil(t, validateCardCode("4 S"))
-	assert.NotNil(t, validateCardCode("11S"))
-	assert.NotNil(t, validateCardCode("10"))
-	assert.NotNil(t, validateCardCode("4h"))
-	assert.NotNil(t, validateCardCode("1S"))
-	assert.NotNil(t, validateCardCode(""))
-	assert.NotNil(t, validateCardCode("  "))
+	assert.Nil(t, validateCardCode("AH", standard))
+	assert.Nil(t, validateCardCode("KC", standard))
+	assert.Nil(t, validateCardCode("JD", standard))
+	assert.Nil(t, validateCardCode("QS", standard))
+	assert.Nil(t, validateCardCode("10D", standard))
+	assert.Nil(t, validateCardCode("4C", standard))
+	assert.Nil(t, validateCardCode("4h", standard))
+	assert.Nil(t, validateCardCode("TS", standard))
+	assert.Nil(t, validateCardCode("10♥", standard))
+	assert.NotNil(t, validateCardCode("4 S", standard))
+	assert.NotNil(t, validateCardCode("11S", standard))
+	assert.NotNil(t, validateCardCode("10", standard))
+	assert.NotNil(t, validateCardCode("♥", standard))
+	assert.NotNil(t, validateCardCode("T♥S", standard))
+	assert.NotNil(t, validateCardCode("1S", standard))
+	assert.NotNil(t, validateCardCode("", standard))
+	assert.NotNil(t, validateCardCode("  ", standard))
+}
+
+func TestNewShoe(t *testing.T) {
+	shoe, error := service.CreateDeck(DeckOptions{DecksCount: 6, Shuffle: true})
+	assert.Nil(t, error)
+	assert.Equal(t, 312, len(shoe.Cards))
+	assert.Equal(t, 312, shoe.Size)
+	assert.Equal(t, 6, shoe.DecksCount)
+	assert.Equal(t, 6.0, shoe.RemainingDecks())
+
+	// every card is in the shoe once per deck
+	counts := map[string]int{}
+	for _, card := range shoe.Cards {
+		counts[card.Code()]++
+	}
+	assert.Equal(t, 52, len(counts))
+	for code, count := range counts {
+		assert.Equal(t, 6, count, "card %v", code)
+	}
+
+	// custom cards are repeated as well
+	shoe, error = service.CreateDeck(DeckOptions{DecksCount: 2, Codes: "AS,KH"})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "KH", "AS", "KH"}, cardCodes(shoe.Cards))
+
+	// a single deck is a shoe of one deck
+	d, _ := service.CreateNewDeck(false, "")
+	assert.Equal(t, 1, d.DecksCount)
+	assert.Equal(t, 52, d.Size)
+}
+
+func TestShoeCutCard(t *testing.T) {
+	shoe, error := service.CreateDeck(DeckOptions{DecksCount: 2, Penetration: 0.75})
+	assert.Nil(t, error)
+	assert.Equal(t, 78, shoe.CutCard)
+	deck_id := shoe.DeckId.String()
+
+	_, d, error := service.Draw(deck_id, DrawOptions{Count: 77})
+	assert.Nil(t, error)
+	assert.Equal(t, 77, d.Dealt())
+	assert.False(t, d.NeedsReshuffle())
+
+	_, d, error = service.Draw(deck_id, DrawOptions{Count: 1})
+	assert.Nil(t, error)
+	assert.True(t, d.NeedsReshuffle())
+	assert.Equal(t, 0.5, d.RemainingDecks())
+
+	// drawing past the cut card is still possible, e.g. to finish a round
+	_, d, error = service.Draw(deck_id, DrawOptions{Count: 5})
+	assert.Nil(t, error)
+	assert.True(t, d.NeedsReshuffle())
+
+	// no cut card without penetration
+	d, _ = service.CreateDeck(DeckOptions{DecksCount: 2})
+	d.Cards = nil
+	assert.False(t, d.NeedsReshuffle())
+}
+
+func TestNewShoeInvalidOptions(t *testing.T) {
+	_, error := service.CreateDeck(DeckOptions{DecksCount: -1})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{DecksCount: MaxDecksCount + 1})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{Penetration: 1.5})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{Penetration: -0.5})
+	assert.NotNil(t, error)
+	_, error = service.CreateDeck(DeckOptions{DecksCount: 6, Penetration: math.NaN()})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
 }
@@ -0,0 +1,217 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Options to draw cards from a deck. Cards are drawn either by count, by
+codes or until a predicate matches, only one of them can be given
+*/
+type DrawOptions struct {
+	// number of cards to draw
+	Count int
+	// top, bottom or random, top if empty. Position from which cards are drawn by count or until
+	From string
+	// comma separated list of codes of cards to draw from anywhere in the deck
+	Codes string
+	// cards are drawn up to and including the first card which matches, see ParsePredicate
+	Until string
+}
+
+// function which tells whether a card matches a condition
+type CardPredicate func(card Card) bool
+
+/*
+Draws a hand of cards as given by the options
+inputs:
+	deckId  :  a UUID in string format
+	options :  see DrawOptions
+returns:
+	a slice of cards representing a hand
+	the deck after the draw, e.g. to check whether the cut card of a shoe was reached
+	error if UUID is not valid, deck not found, options are invalid or
+	the cards are not available
+*/
+func (s *Service) Draw(deckId string, options DrawOptions) ([]Card, Deck, error) {
+	var hand []Card
+	if e := options.Validate(); e != nil {
+		return hand, Deck{}, e
+	}
+	from, _ := normalizeFrom(options.From)
+	codes := strings.TrimSpace(options.Codes)
+	until := strings.TrimSpace(options.Until)
+	var predicate CardPredicate
+	if len(until) > 0 {
+		predicate, _ = ParsePredicate(until)
+	}
+
+	d, e := s.mutate(deckId, func(deck *Deck) error {
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot draw any cards, deck is closed")
+		}
+		if len(deck.Cards) == 0 {
+			return newError(ErrInsufficientCards, "cannot draw any cards, deck is empty")
+		}
+
+		// cards drawn by code are recorded without position
+		event := Event{Type: EventDraw}
+		switch {
+		case len(codes) > 0:
+			var e error
+			hand, deck.Cards, e = takeCards(deck.Cards, deck.parseCodes(codes))
+			if e != nil {
+				return fmt.Errorf("cannot draw cards: %w", e)
+			}
+		case predicate != nil:
+			count := countUntil(deck.Cards, predicate, from)
+			if count == 0 {
+				message := fmt.Sprintf("no card in the deck matches '%v'", until)
+				return newError(ErrCardNotAvailable, message)
+			}
+			hand, deck.Cards, event.Indices = s.takeFrom(deck.Cards, count, from)
+			event.Position = from
+		default:
+			if options.Count > len(deck.Cards) {
+				message := fmt.Sprintf("cannot draw %d cards, deck has only %d", options.Count, len(deck.Cards))
+				return newError(ErrInsufficientCards, message)
+			}
+			hand, deck.Cards, event.Indices = s.takeFrom(deck.Cards, options.Count, from)
+			event.Position = from
+		}
+		deck.Drawn = append(deck.Drawn, hand...)
+		event.Cards = hand
+		deck.record(event)
+		return nil
+	})
+	if e != nil {
+		return nil, d, e
+	}
+	return hand, d, nil
+}
+
+/*
+Returns error if the options are invalid regardless of the deck, e.g. count
+is given together with codes or the position is unknown
+*/
+func (o DrawOptions) Validate() error {
+	from, e := normalizeFrom(o.From)
+	if e != nil {
+		return e
+	}
+	codes := strings.TrimSpace(o.Codes)
+	until := strings.TrimSpace(o.Until)
+	switch {
+	case len(codes) > 0 && len(until) > 0:
+		return newError(ErrInvalidArgument, "codes and until cannot be used together")
+	case (len(codes) > 0 || len(until) > 0) && o.Count != 0:
+		return newError(ErrInvalidArgument, "count cannot be used with codes or until")
+	case len(codes) > 0 && from != FromTop:
+		return newError(ErrInvalidArgument, "cards drawn by code are taken from anywhere in the deck")
+	case len(until) > 0:
+		if from == FromRandom {
+			return newError(ErrInvalidArgument, "until can only be used from top or bottom")
+		}
+		_, e = ParsePredicate(until)
+		return e
+	case len(codes) == 0 && o.Count <= 0:
+		return newError(ErrInvalidArgument, "count must be more than zero")
+	}
+	return nil
+}
+
+// returns the number of cards up to and including the first match from top or bottom, 0 if none matches
+func countUntil(cards []Card, predicate CardPredicate, from string) int {
+	for i := range cards {
+		card := cards[i]
+		if from == FromBottom {
+			card = cards[len(cards)-1-i]
+		}
+		if predicate(card) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+/*
+Parses a condition on cards, case insensitive. Supported conditions are
+	a card code            :  QH, 10♠
+	a rank                 :  A, ace, 10, K, king
+	a suit                 :  H, hearts, ♥
+	a color                :  red, black
+	face or joker          :  any jack, queen or king, any joker
+	attribute=value        :  cards of a custom card set with the attribute, e.g. color=red
+	a code of a custom set :  matches custom cards with that code, case sensitive
+inputs:
+	condition :  the condition
+returns:
+	the predicate
+	error if the condition is empty
+*/
+func ParsePredicate(condition string) (CardPredicate, error) {
+	condition = strings.TrimSpace(condition)
+	if len(condition) == 0 {
+		return nil, newError(ErrInvalidArgument, "condition must not be empty")
+	}
+	if key, value, found := strings.Cut(condition, "="); found {
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		return func(card Card) bool {
+			if card.Custom == nil {
+				return false
+			}
+			attribute, exists := card.Custom.Attributes[key]
+			return exists && fmt.Sprint(attribute) == value
+		}, nil
+	}
+
+	upper := strings.ToUpper(condition)
+	switch upper {
+	case "FACE":
+		return func(card Card) bool { return card.Rank.IsFace() }, nil
+	case "JOKER":
+		return Card.IsJoker, nil
+	case Red.String(), Black.String():
+		return func(card Card) bool { return !card.IsCustom() && card.Suit.Color().String() == upper }, nil
+	}
+	if rank := parseRankName(upper); rank != NoRank {
+		return func(card Card) bool { return !card.IsCustom() && card.Rank == rank }, nil
+	}
+	if suit := parseSuitName(upper); suit != NoSuit {
+		return func(card Card) bool { return !card.IsCustom() && card.Suit == suit }, nil
+	}
+	if parsed, e := ParseCard(condition); e == nil {
+		return func(card Card) bool { return !card.IsCustom() && card.Code() == parsed.Code() }, nil
+	}
+	return func(card Card) bool { return card.IsCustom() && card.Code() == condition }, nil
+}
+
+// returns the rank for an upper case rank code or name, NoRank if unknown
+func parseRankName(s string) Rank {
+	if rank := parseRank(s); rank != NoRank {
+		return rank
+	}
+	for _, rank := range ranks {
+		if s == rank.Name() {
+			return rank
+		}
+	}
+	return NoRank
+}
+
+// returns the suit for an upper case letter, symbol or name, NoSuit if unknown
+func parseSuitName(s string) Suit {
+	if suit := parseSuit(s); suit != NoSuit {
+		return suit
+	}
+	for _, suit := range suits {
+		if s == suitNames[suit] {
+			return suit
+		}
+	}
+	if s == LegacyDiamondsName {
+		return Diamonds
+	}
+	return NoSuit
+}
@@ -0,0 +1,121 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrawFromBottomAndRandom(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+
+	hand, deck, error := service.Draw(deck_id, DrawOptions{Count: 2, From: FromBottom})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"10H", "KH"}, cardCodes(hand))
+	assert.Equal(t, []string{"AS", "KD", "AC", "2C"}, cardCodes(deck.Cards))
+
+	hand, deck, error = service.Draw(deck_id, DrawOptions{Count: 3, From: FromRandom})
+	assert.Nil(t, error)
+	assert.Equal(t, 3, len(hand))
+	assert.Equal(t, 1, len(deck.Cards))
+	assertDealtExactlyOnce(t, d.Cards, [][]Card{deck.Drawn, deck.Cards})
+
+	_, _, error = service.Draw(deck_id, DrawOptions{Count: 2, From: FromRandom})
+	assert.NotNil(t, error)
+	_, _, error = service.Draw(deck_id, DrawOptions{Count: 1, From: "middle"})
+	assert.NotNil(t, error)
+}
+
+func TestDrawByCode(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "")
+	deck_id := d.DeckId.String()
+
+	hand, deck, error := service.Draw(deck_id, DrawOptions{Codes: "qh, 10S"})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"QH", "10S"}, cardCodes(hand))
+	assert.Equal(t, 50, len(deck.Cards))
+	assert.Equal(t, "AS", deck.Cards[0].Code())
+
+	// a card can only be drawn once
+	_, _, error = service.Draw(deck_id, DrawOptions{Codes: "QH"})
+	assert.NotNil(t, error)
+	_, _, error = service.Draw(deck_id, DrawOptions{Codes: "AS", Count: 1})
+	assert.NotNil(t, error)
+	_, _, error = service.Draw(deck_id, DrawOptions{Codes: "AS", From: FromBottom})
+	assert.NotNil(t, error)
+}
+
+func TestDrawUntil(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "2S,KD,AC,2C,AH,10H")
+	deck_id := d.DeckId.String()
+
+	hand, _, error := service.Draw(deck_id, DrawOptions{Until: "ace"})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"2S", "KD", "AC"}, cardCodes(hand))
+
+	hand, _, error = service.Draw(deck_id, DrawOptions{Until: "red", From: FromBottom})
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"10H"}, cardCodes(hand))
+
+	// no match leaves the deck unchanged
+	_, deck, error := service.Draw(deck_id, DrawOptions{Until: "K"})
+	assert.NotNil(t, error)
+	deck, _ = service.OpenDeck(deck_id)
+	assert.Equal(t, []string{"2C", "AH"}, cardCodes(deck.Cards))
+
+	_, _, error = service.Draw(deck_id, DrawOptions{Until: "ace", Codes: "2C"})
+	assert.NotNil(t, error)
+	_, _, error = service.Draw(deck_id, DrawOptions{Until: "ace", From: FromRandom})
+	assert.NotNil(t, error)
+}
+
+func TestValidateDrawOptions(t *testing.T) {
+	assert.Nil(t, DrawOptions{Count: 2, From: "Bottom"}.Validate())
+	assert.Nil(t, DrawOptions{Codes: "AS,KD"}.Validate())
+	assert.Nil(t, DrawOptions{Until: "face", From: FromBottom}.Validate())
+
+	assert.NotNil(t, DrawOptions{}.Validate())
+	assert.NotNil(t, DrawOptions{Count: 1, From: "middle"}.Validate())
+	assert.NotNil(t, DrawOptions{Count: 1, Codes: "AS"}.Validate())
+	assert.NotNil(t, DrawOptions{Codes: "AS", Until: "face"}.Validate())
+	assert.NotNil(t, DrawOptions{Codes: "AS", From: FromRandom}.Validate())
+	assert.NotNil(t, DrawOptions{Until: "face", From: FromRandom}.Validate())
+	assert.NotNil(t, DrawOptions{Until: " "}.Validate())
+}
+
+func TestParsePredicate(t *testing.T) {
+	queen := Card{Rank: Queen, Suit: Hearts}
+	two := Card{Rank: Two, Suit: Spades}
+	joker := Card{Rank: Joker}
+	custom := NewCustomCard("set", CardDefinition{Code: "R1", Attributes: map[string]interface{}{"color": "red", "number": 1.0}})
+
+	matches := func(condition string, card Card) bool {
+		predicate, error := ParsePredicate(condition)
+		assert.Nil(t, error, condition)
+		return predicate(card)
+	}
+	assert.True(t, matches("QH", queen))
+	assert.True(t, matches("q♥", queen))
+	assert.False(t, matches("QH", two))
+	assert.True(t, matches("queen", queen))
+	assert.True(t, matches("Q", queen))
+	assert.True(t, matches("2", two))
+	assert.True(t, matches("hearts", queen))
+	assert.True(t, matches("♠", two))
+	assert.True(t, matches("dimonds", Card{Rank: Ace, Suit: Diamonds}))
+	assert.True(t, matches("red", queen))
+	assert.False(t, matches("red", two))
+	assert.True(t, matches("face", queen))
+	assert.False(t, matches("face", two))
+	assert.True(t, matches("joker", joker))
+	assert.True(t, matches("color=red", custom))
+	assert.True(t, matches("number=1", custom))
+	assert.False(t, matches("color=red", queen))
+	assert.True(t, matches("R1", custom))
+	assert.False(t, matches("r1", custom))
+	assert.False(t, matches("red", custom))
+
+	_, error := ParsePredicate(" ")
+	assert.NotNil(t, error)
+}
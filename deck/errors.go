@@ -0,0 +1,43 @@
+package deck
+
+import "errors"
+
+/*
+Kinds of errors returned by the service besides the errors of deck stores and
+card sets. Errors carry their own message and match their kind with errors.Is,
+e.g. errors.Is(e, ErrInsufficientCards)
+*/
+var (
+	ErrInvalidDeckId     = errors.New("deck id is not a valid UUID")
+	ErrInvalidCard       = errors.New("invalid card")
+	ErrInvalidCardSet    = errors.New("invalid card set")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrInsufficientCards = errors.New("not enough cards")
+	ErrCardNotAvailable  = errors.New("card not available")
+	ErrDeckClosed        = errors.New("deck is closed")
+	ErrPileNotFound      = errors.New("pile not found")
+	ErrSnapshotNotFound  = errors.New("snapshot not found")
+	ErrTooManySnapshots  = errors.New("too many snapshots")
+	ErrNothingToUndo     = errors.New("nothing to undo or redo")
+	ErrSeedNotRevealed   = errors.New("server seed cannot be revealed")
+	ErrInvalidHistory    = errors.New("history cannot be replayed")
+)
+
+// error with its own message which matches its kind with errors.Is
+type deckError struct {
+	kind    error
+	message string
+}
+
+func (e *deckError) Error() string {
+	return e.message
+}
+
+func (e *deckError) Unwrap() error {
+	return e.kind
+}
+
+// returns an error with the message, which is of the given kind
+func newError(kind error, message string) error {
+	return &deckError{kind: kind, message: message}
+}
@@ -0,0 +1,48 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD")
+	deck_id := d.DeckId.String()
+
+	_, error := service.OpenDeck("1234")
+	assert.True(t, errors.Is(error, ErrInvalidDeckId))
+	assert.Equal(t, "input UUID '1234' is not a valid UUID4 value", error.Error())
+	_, error = service.OpenDeck(uuid.New().String())
+	assert.True(t, errors.Is(error, ErrDeckNotFound))
+	assert.False(t, errors.Is(error, ErrInvalidDeckId))
+
+	_, error = service.CreateNewDeck(false, "AS,ZZ")
+	assert.True(t, errors.Is(error, ErrInvalidCard))
+	_, error = service.CreateDeck(DeckOptions{DecksCount: MaxDecksCount + 1})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+	_, error = service.CreateDeck(DeckOptions{CardSet: "unknown"})
+	assert.True(t, errors.Is(error, ErrCardSetNotFound))
+
+	_, error = service.DrawCards(deck_id, 0)
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+	_, error = service.DrawCards(deck_id, 3)
+	assert.True(t, errors.Is(error, ErrInsufficientCards))
+	assert.Equal(t, "cannot draw 3 cards, deck has only 2", error.Error())
+	_, _, error = service.Draw(deck_id, DrawOptions{Codes: "QH"})
+	assert.True(t, errors.Is(error, ErrCardNotAvailable))
+	_, error = service.Pile(deck_id, "discard")
+	assert.True(t, errors.Is(error, ErrPileNotFound))
+	_, error = service.RestoreSnapshot(deck_id, "missing")
+	assert.True(t, errors.Is(error, ErrSnapshotNotFound))
+	_, error = service.Undo(deck_id, 1, currentVersion(deck_id))
+	assert.True(t, errors.Is(error, ErrNothingToUndo))
+	_, error = service.RevealSeed(deck_id)
+	assert.True(t, errors.Is(error, ErrSeedNotRevealed))
+
+	service.CloseDeck(deck_id)
+	_, error = service.DrawCards(deck_id, 1)
+	assert.True(t, errors.Is(error, ErrDeckClosed))
+}
@@ -0,0 +1,88 @@
+package deck
+
+import "time"
+
+// types of the operations recorded in the history of a deck
+const (
+	EventCreate       = "create"
+	EventShuffle      = "shuffle"
+	EventDraw         = "draw"
+	EventPeek         = "peek" // recorded by earlier versions only, peeks do not change the deck
+	EventCut          = "cut"
+	EventBurn         = "burn"
+	EventDeal         = "deal"
+	EventAddToPile    = "add_to_pile"
+	EventDrawFromPile = "draw_from_pile"
+	EventMoveCards    = "move_cards"
+	EventReturnCards  = "return_cards"
+	EventReturnPile   = "return_pile"
+	EventReshuffle    = "reshuffle"
+	EventClose        = "close"
+	EventClone        = "clone"
+	EventSnapshot     = "snapshot"
+	EventRestore      = "restore"
+	EventUndo         = "undo"
+	EventRedo         = "redo"
+)
+
+// name of the pile burned cards are placed on
+const BurnPile = "burn"
+
+/*
+Type to record an operation on a deck. Random outcomes are resolved, i.e.
+an event holds the cards and positions the operation ended up with, so that
+applying the events in order always gives the same deck
+*/
+type Event struct {
+	Type string `json:"type"`
+	// time of the operation in UTC
+	Timestamp time.Time `json:"timestamp"`
+	// cards the operation was applied to: the new order of the deck for create,
+	// shuffle and reshuffle, otherwise the cards drawn, peeked, burned, moved or returned
+	Cards []Card `json:"cards,omitempty"`
+	// pile the cards are taken from or placed on
+	Pile string `json:"pile,omitempty"`
+	// pile cards are moved to
+	To string `json:"to,omitempty"`
+	// top, bottom or random, position in the deck or pile cards are taken from or returned to.
+	// Empty for cards drawn by code
+	Position string `json:"position,omitempty"`
+	// number of cards moved from top to bottom by a cut
+	CutAt int `json:"cut_at,omitempty"`
+	// for random positions, the index of each card when it was taken from or
+	// inserted into the deck or pile, one card after another
+	Indices []int `json:"indices,omitempty"`
+	// number of players and order of a deal, see Deal
+	Players int    `json:"players,omitempty"`
+	Order   string `json:"order,omitempty"`
+	// shuffle method, passes and seed of a shuffle or reshuffle
+	ShuffleMethod string `json:"shuffle_method,omitempty"`
+	ShufflePasses int    `json:"shuffle_passes,omitempty"`
+	Seed          *int64 `json:"seed,omitempty"`
+	// id of the deck a clone is made from
+	Source string `json:"source,omitempty"`
+	// name of a snapshot which is saved or restored
+	Snapshot string `json:"snapshot,omitempty"`
+	// indices in the history of the events an undo reverts or a redo applies again, or of the snapshot a restore goes back to
+	Events []int `json:"events,omitempty"`
+	// set while the operation is reverted by an undo
+	Reverted bool `json:"reverted,omitempty"`
+}
+
+// returns true for events which change the deck and can be undone, i.e. not for undo, redo, peek and snapshot events
+func (e Event) isOperation() bool {
+	switch e.Type {
+	case EventUndo, EventRedo, EventPeek, EventSnapshot:
+		return false
+	}
+	return true
+}
+
+// appends the event to the history of the deck
+func (d *Deck) record(event Event) {
+	event.Timestamp = time.Now().UTC()
+	if event.Cards != nil {
+		event.Cards = cloneCards(event.Cards)
+	}
+	d.History = append(d.History, event)
+}
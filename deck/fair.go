@@ -0,0 +1,215 @@
+package deck
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// number of random bytes in a server seed
+const serverSeedSize = 32
+
+// maximum number of committed server seeds which wait for their deck, older seeds are dropped
+const MaxPendingServerSeeds = 10000
+
+/*
+Everything needed to verify a provably fair shuffle.
+Before the seed is revealed only the hash of the server seed and the
+commitment are published. Once the server seed is revealed anyone can
+recompute the shuffled order from the seeds and check it against the
+commitment with VerifyShuffle.
+*/
+type ShuffleProof struct {
+	// hex encoded random seed chosen by the server, secret until revealed
+	ServerSeed string `json:"server_seed"`
+	// hex encoded SHA-256 of the server seed, published before the client seed is chosen
+	ServerSeedHash string `json:"server_seed_hash,omitempty"`
+	// seed chosen by the client, so that the server alone cannot pick the order
+	ClientSeed string `json:"client_seed"`
+	// hex encoded SHA-256 of server seed and shuffled order, see Commitment
+	Commitment    string `json:"commitment"`
+	ShuffleMethod string `json:"shuffle_method"`
+	ShufflePasses int    `json:"shuffle_passes"`
+	// card codes in the order before the shuffle
+	InitialOrder []string `json:"initial_order"`
+}
+
+/*
+Returns the commitment to a shuffle: the hex encoded SHA-256 of
+"<server seed>:<comma separated card codes in shuffled order>"
+*/
+func Commitment(serverSeed string, order []string) string {
+	sum := sha256.Sum256([]byte(serverSeed + ":" + strings.Join(order, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// returns the hex encoded SHA-256 of the server seed
+func ServerSeedHash(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+Recomputes the shuffle from the seeds in the proof and checks it against
+the commitment and the server seed against its hash
+inputs:
+	proof :  revealed proof of a shuffle
+returns:
+	card codes in shuffled order
+	error if the server seed does not match its hash or the recomputed order
+	does not match the commitment
+*/
+func VerifyShuffle(proof ShuffleProof) ([]string, error) {
+	if len(proof.ServerSeedHash) > 0 && ServerSeedHash(proof.ServerSeed) != proof.ServerSeedHash {
+		return nil, errors.New("server seed does not match its hash")
+	}
+	shuffler, err := NewShuffler(proof.ShuffleMethod, proof.ShufflePasses)
+	if err != nil {
+		return nil, err
+	}
+	// a shuffle only moves cards, so cards are represented by their codes,
+	// which also works for cards of custom card sets
+	cards := make([]Card, len(proof.InitialOrder))
+	for i, code := range proof.InitialOrder {
+		cards[i] = NewCustomCard("", CardDefinition{Code: code})
+	}
+	shuffler.Shuffle(cards, seedsRand(proof.ServerSeed, proof.ClientSeed))
+
+	order := cardCodes(cards)
+	if Commitment(proof.ServerSeed, order) != proof.Commitment {
+		return order, errors.New("shuffle does not match the commitment")
+	}
+	return order, nil
+}
+
+/*
+Commits to a new server seed for a provably fair shuffle with a client seed.
+The client chooses its seed only after it has the hash, so the server cannot
+pick a seed which gives an order it likes. Each committed seed shuffles a
+single deck, see DeckOptions.ServerSeedHash
+returns:
+	hex encoded SHA-256 of the new server seed
+*/
+func (s *Service) CommitServerSeed() string {
+	seed := s.newServerSeed()
+	hash := ServerSeedHash(seed)
+	s.seedsMu.Lock()
+	defer s.seedsMu.Unlock()
+	if s.pendingSeeds == nil {
+		s.pendingSeeds = map[string]string{}
+	}
+	if len(s.pendingSeedOrder) >= MaxPendingServerSeeds {
+		delete(s.pendingSeeds, s.pendingSeedOrder[0])
+		s.pendingSeedOrder = s.pendingSeedOrder[1:]
+	}
+	s.pendingSeeds[hash] = seed
+	s.pendingSeedOrder = append(s.pendingSeedOrder, hash)
+	return hash
+}
+
+// returns the committed server seed with the given hash, which cannot be used again
+func (s *Service) takeServerSeed(hash string) (string, error) {
+	s.seedsMu.Lock()
+	defer s.seedsMu.Unlock()
+	seed, exists := s.pendingSeeds[hash]
+	if !exists {
+		message := fmt.Sprintf("server seed hash '%v' is unknown or already used", hash)
+		return "", newError(ErrInvalidArgument, message)
+	}
+	delete(s.pendingSeeds, hash)
+	for i, pending := range s.pendingSeedOrder {
+		if pending == hash {
+			s.pendingSeedOrder = append(s.pendingSeedOrder[:i], s.pendingSeedOrder[i+1:]...)
+			break
+		}
+	}
+	return seed, nil
+}
+
+/*
+Shuffles the cards with randomness derived only from the server seed and the
+client seed, and returns the proof for the shuffle. The server seed is the
+committed seed with the given hash, or a new seed if there is no client seed
+*/
+func (s *Service) fairShuffle(cards []Card, method string, passes int, clientSeed string, serverSeedHash string) (*ShuffleProof, error) {
+	shuffler, err := NewShuffler(method, passes)
+	if err != nil {
+		return nil, err
+	}
+	var serverSeed string
+	if len(serverSeedHash) > 0 {
+		if serverSeed, err = s.takeServerSeed(serverSeedHash); err != nil {
+			return nil, err
+		}
+	} else if len(clientSeed) > 0 {
+		return nil, newError(ErrInvalidArgument, "client seed needs the hash of a committed server seed")
+	} else {
+		serverSeed = s.newServerSeed()
+	}
+	proof := &ShuffleProof{
+		ServerSeed:     serverSeed,
+		ServerSeedHash: ServerSeedHash(serverSeed),
+		ClientSeed:     clientSeed,
+		ShuffleMethod:  method,
+		ShufflePasses:  passes,
+		InitialOrder:   cardCodes(cards),
+	}
+	shuffler.Shuffle(cards, seedsRand(proof.ServerSeed, proof.ClientSeed))
+	proof.Commitment = Commitment(proof.ServerSeed, cardCodes(cards))
+	return proof, nil
+}
+
+// returns a hex encoded server seed read from the random source of the service
+func (s *Service) newServerSeed() string {
+	seed := make([]byte, serverSeedSize)
+	s.randomMu.Lock()
+	s.random.Read(seed)
+	s.randomMu.Unlock()
+	return hex.EncodeToString(seed)
+}
+
+/*
+Returns the generator used for a provably fair shuffle. Its output is the
+SHA-256 of "<server seed>:<client seed>:<counter>" for counter 0, 1, 2 ...
+so the full entropy of both seeds is used
+*/
+func seedsRand(serverSeed string, clientSeed string) *rand.Rand {
+	return rand.New(&seedsSource{key: serverSeed + ":" + clientSeed})
+}
+
+// deterministic rand.Source64 built on SHA-256 in counter mode
+type seedsSource struct {
+	key     string
+	counter uint64
+	block   []byte
+}
+
+func (s *seedsSource) Uint64() uint64 {
+	if len(s.block) == 0 {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%v:%d", s.key, s.counter)))
+		s.counter++
+		s.block = sum[:]
+	}
+	n := binary.BigEndian.Uint64(s.block[:8])
+	s.block = s.block[8:]
+	return n
+}
+
+func (s *seedsSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *seedsSource) Seed(int64) {}
+
+// returns codes of the cards in order
+func cardCodes(cards []Card) []string {
+	codes := make([]string, len(cards))
+	for i, card := range cards {
+		codes[i] = card.Code()
+	}
+	return codes
+}
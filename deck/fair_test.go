@@ -0,0 +1,155 @@
+package deck
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairShuffleCommitmentAndReveal(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	hash := s.CommitServerSeed()
+	d, error := s.CreateDeck(DeckOptions{Shuffle: true, ShuffleMethod: "riffle", ClientSeed: "lucky", ServerSeedHash: hash})
+	assert.Nil(t, error)
+	assert.NotNil(t, d.Proof)
+	assert.Equal(t, hash, d.Proof.ServerSeedHash)
+	assert.Equal(t, hash, ServerSeedHash(d.Proof.ServerSeed))
+	assert.Equal(t, Commitment(d.Proof.ServerSeed, cardCodes(d.Cards)), d.Proof.Commitment)
+	deckId := d.DeckId.String()
+
+	// seed cannot be revealed while cards can still be drawn
+	_, error = s.RevealSeed(deckId)
+	assert.NotNil(t, error)
+
+	s.DrawCards(deckId, 52)
+	proof, error := s.RevealSeed(deckId)
+	assert.Nil(t, error)
+	assert.Equal(t, "lucky", proof.ClientSeed)
+	assert.Equal(t, hash, proof.ServerSeedHash)
+	assert.Equal(t, "riffle", proof.ShuffleMethod)
+	assert.Equal(t, cardCodes(newSequentialDeck().Cards), proof.InitialOrder)
+
+	order, error := VerifyShuffle(proof)
+	assert.Nil(t, error)
+	assert.Equal(t, cardCodes(d.Cards), order)
+}
+
+func TestRevealSeedOfClosedDeck(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	d, _ := s.CreateNewDeck(true, "AS,KD,AC")
+	deckId := d.DeckId.String()
+
+	closed, error := s.CloseDeck(deckId)
+	assert.Nil(t, error)
+	assert.True(t, closed.Closed)
+
+	// closed deck cannot be drawn from
+	_, error = s.DrawCards(deckId, 1)
+	assert.NotNil(t, error)
+
+	proof, error := s.RevealSeed(deckId)
+	assert.Nil(t, error)
+	_, error = VerifyShuffle(proof)
+	assert.Nil(t, error)
+}
+
+func TestRevealSeedWithoutProof(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	seed := int64(5)
+	unshuffled, _ := s.CreateNewDeck(false, "AS")
+	seeded, _ := s.CreateDeck(DeckOptions{Shuffle: true, Codes: "AS", Seed: &seed})
+	// a clone of a provably fair deck cannot reveal the seed of the original
+	fair, _ := s.CreateNewDeck(true, "AS,KD")
+	clone, _ := s.CloneDeck(fair.DeckId.String())
+	assert.Nil(t, clone.Proof)
+
+	for _, d := range []Deck{unshuffled, seeded, clone} {
+		s.CloseDeck(d.DeckId.String())
+		_, error := s.RevealSeed(d.DeckId.String())
+		assert.NotNil(t, error)
+	}
+}
+
+func TestVerifyShuffleDetectsTampering(t *testing.T) {
+	s := NewService(NewMemoryStore(), WithRandomSource(rand.NewSource(1)))
+	d, _ := s.CreateNewDeck(true, "")
+	proof := *d.Proof
+
+	// another server seed gives another order
+	tampered := proof
+	tampered.ServerSeed = "00" + proof.ServerSeed[2:]
+	_, error := VerifyShuffle(tampered)
+	assert.NotNil(t, error)
+
+	// so does another client seed
+	tampered = proof
+	tampered.ClientSeed = "other"
+	_, error = VerifyShuffle(tampered)
+	assert.NotNil(t, error)
+
+	// even if the hash of the server seed is changed too
+	tampered.ServerSeedHash = ServerSeedHash(tampered.ServerSeed)
+	_, error = VerifyShuffle(tampered)
+	assert.NotNil(t, error)
+
+	// the server seed must match the hash published before the client seed was chosen
+	tampered = proof
+	tampered.ServerSeedHash = ServerSeedHash("other")
+	_, error = VerifyShuffle(tampered)
+	assert.NotNil(t, error)
+
+	// and a commitment to another order
+	tampered = proof
+	order := cardCodes(d.Cards)
+	order[0], order[1] = order[1], order[0]
+	tampered.Commitment = Commitment(proof.ServerSeed, order)
+	_, error = VerifyShuffle(tampered)
+	assert.NotNil(t, error)
+}
+
+func TestClientSeedChangesOrder(t *testing.T) {
+	first := NewService(NewMemoryStore(), WithRandomSource(rand.NewSource(9)))
+	second := NewService(NewMemoryStore(), WithRandomSource(rand.NewSource(9)))
+
+	d1, _ := first.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "a", ServerSeedHash: first.CommitServerSeed()})
+	d2, _ := second.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "b", ServerSeedHash: second.CommitServerSeed()})
+	assert.Equal(t, d1.Proof.ServerSeed, d2.Proof.ServerSeed)
+	assert.NotEqual(t, d1.Cards, d2.Cards)
+}
+
+func TestClientSeedNeedsCommittedServerSeed(t *testing.T) {
+	s := NewService(NewMemoryStore())
+
+	// the server could choose its seed after seeing the client seed
+	_, error := s.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "a"})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+
+	_, error = s.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "a", ServerSeedHash: ServerSeedHash("guess")})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+
+	// a committed seed shuffles a single deck
+	hash := s.CommitServerSeed()
+	_, error = s.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "a", ServerSeedHash: hash})
+	assert.Nil(t, error)
+	_, error = s.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "b", ServerSeedHash: hash})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+
+	// without client seed the server seed may be committed too
+	hash = s.CommitServerSeed()
+	d, error := s.CreateDeck(DeckOptions{Shuffle: true, ServerSeedHash: hash})
+	assert.Nil(t, error)
+	assert.Equal(t, hash, d.Proof.ServerSeedHash)
+}
+
+func TestPendingServerSeedsAreBounded(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	oldest := s.CommitServerSeed()
+	for i := 0; i < MaxPendingServerSeeds; i++ {
+		s.CommitServerSeed()
+	}
+	assert.Equal(t, MaxPendingServerSeeds, len(s.pendingSeeds))
+	_, error := s.CreateDeck(DeckOptions{Shuffle: true, ClientSeed: "a", ServerSeedHash: oldest})
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+}
@@ -0,0 +1,190 @@
+package deck
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// extension of the files in which decks are persisted
+const deckFileExt = ".json"
+
+/*
+DeckStore which persists every deck as a JSON file in a data directory.
+All decks are also kept in memory, so reads never touch the disk.
+Writes are crash-safe: a deck is written to a temporary file which is synced
+and then atomically renamed over the previous version of the deck.
+*/
+type fileStore struct {
+	dir string
+	// serializes writes so that file and cache are always updated together
+	mu    sync.Mutex
+	cache *memoryStore
+}
+
+/*
+Returns a DeckStore which persists decks in given directory.
+The directory is created if it does not exist and all decks found in it are loaded.
+inputs:
+	dir :  path of the data directory
+returns:
+	a store containing all previously persisted decks
+	error if directory cannot be created or a deck file cannot be read
+*/
+func NewFileStore(dir string) (DeckStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create data directory %v: %w", dir, err)
+	}
+	s := &fileStore{dir: dir, cache: &memoryStore{decks: map[uuid.UUID]Deck{}}}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileStore) Get(id uuid.UUID) (Deck, error) {
+	return s.cache.Get(id)
+}
+
+func (s *fileStore) List() ([]Deck, error) {
+	return s.cache.List()
+}
+
+func (s *fileStore) ListPage(limit int, after *ListPosition) ([]Deck, error) {
+	return s.cache.ListPage(limit, after)
+}
+
+func (s *fileStore) Put(d Deck) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(d); err != nil {
+		return err
+	}
+	return s.cache.Put(d)
+}
+
+func (s *fileStore) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.cache.Get(id); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot delete deck %v: %w", id, err)
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+	return s.cache.Delete(id)
+}
+
+func (s *fileStore) Update(d Deck) (Deck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.cache.Get(d.DeckId)
+	if err != nil {
+		return Deck{}, err
+	}
+	if stored.Version != d.Version {
+		return Deck{}, ErrVersionConflict
+	}
+
+	next := d
+	next.Version++
+	if err := s.write(next); err != nil {
+		return Deck{}, err
+	}
+	// cannot conflict as all writers hold the lock
+	return s.cache.Update(d)
+}
+
+/*
+Reads all deck files from the data directory into the cache.
+Temporary files left behind by an interrupted write are removed.
+*/
+func (s *fileStore) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("cannot read data directory %v: %w", s.dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(s.dir, name)
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".tmp") {
+			os.Remove(path)
+			continue
+		}
+		if !strings.HasSuffix(name, deckFileExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("cannot read deck file %v: %w", path, err)
+		}
+		var d Deck
+		if err := json.Unmarshal(data, &d); err != nil {
+			return fmt.Errorf("cannot parse deck file %v: %w", path, err)
+		}
+		s.cache.Put(d)
+	}
+	return nil
+}
+
+/*
+Atomically writes the deck to its file: the deck is written and synced to a
+temporary file which is then renamed to the deck file, followed by a sync of
+the directory so that the rename itself is durable
+*/
+func (s *fileStore) write(d Deck) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("cannot encode deck %v: %w", d.DeckId, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, d.DeckId.String()+"-*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot write deck %v: %w", d.DeckId, err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(d.DeckId))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("cannot write deck %v: %w", d.DeckId, err)
+	}
+	return syncDir(s.dir)
+}
+
+// returns path of the file in which deck with given id is stored
+func (s *fileStore) path(id uuid.UUID) string {
+	return filepath.Join(s.dir, id.String()+deckFileExt)
+}
+
+// flushes directory entries (file creation, rename, removal) to disk
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("cannot sync data directory %v: %w", dir, err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("cannot sync data directory %v: %w", dir, err)
+	}
+	return nil
+}
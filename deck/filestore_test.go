@@ -0,0 +1,85 @@
+package deck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStoreDecksSurviveReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, error := NewFileStore(dir)
+	assert.Nil(t, error)
+
+	s := NewService(store)
+	d, _ := s.CreateNewDeck(true, "")
+	deckId := d.DeckId.String()
+	hand, _ := s.DrawCards(deckId, 2)
+
+	// reopen store from same directory
+	reopened, error := NewFileStore(dir)
+	assert.Nil(t, error)
+	deck, error := NewService(reopened).OpenDeck(deckId)
+	assert.Nil(t, error)
+	assert.Equal(t, 50, len(deck.Cards))
+	assert.Equal(t, true, deck.Shuffled)
+	assert.Equal(t, d.Cards[2:], deck.Cards)
+	assert.Equal(t, d.Cards[:2], hand)
+	assert.Equal(t, uint64(1), deck.Version)
+	assert.True(t, d.CreatedAt.Equal(deck.CreatedAt))
+}
+
+func TestFileStoreDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFileStore(dir)
+	d := Deck{DeckId: uuid.New()}
+	assert.Nil(t, store.Put(d))
+	assert.FileExists(t, filepath.Join(dir, d.DeckId.String()+".json"))
+
+	assert.Nil(t, store.Delete(d.DeckId))
+	assert.NoFileExists(t, filepath.Join(dir, d.DeckId.String()+".json"))
+	assert.ErrorIs(t, store.Delete(d.DeckId), ErrDeckNotFound)
+
+	reopened, _ := NewFileStore(dir)
+	_, error := reopened.Get(d.DeckId)
+	assert.ErrorIs(t, error, ErrDeckNotFound)
+}
+
+func TestFileStoreUpdateCompareAndSwap(t *testing.T) {
+	store, _ := NewFileStore(t.TempDir())
+	d := newSequentialDeck()
+	d.DeckId = uuid.New()
+	store.Put(d)
+
+	next := d
+	next.Cards = d.Cards[1:]
+	updated, error := store.Update(next)
+	assert.Nil(t, error)
+	assert.Equal(t, uint64(1), updated.Version)
+
+	_, error = store.Update(next)
+	assert.ErrorIs(t, error, ErrVersionConflict)
+}
+
+func TestFileStoreIgnoresInterruptedWrites(t *testing.T) {
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, uuid.New().String()+"-123.tmp")
+	os.WriteFile(tmp, []byte("{\"DeckId\": "), 0o644)
+
+	store, error := NewFileStore(dir)
+	assert.Nil(t, error)
+	decks, _ := store.List()
+	assert.Equal(t, 0, len(decks))
+	assert.NoFileExists(t, tmp)
+}
+
+func TestFileStoreCorruptDeckFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, uuid.New().String()+".json"), []byte("not json"), 0o644)
+
+	_, error := NewFileStore(dir)
+	assert.NotNil(t, error)
+}
@@ -0,0 +1,101 @@
+package deck
+
+import "fmt"
+
+/*
+Returns the top cards of the deck without removing them. A peek does not
+change the deck, so it is neither recorded in the history nor changes the
+version of the deck or counts as an operation for Undo
+inputs:
+	deckId :  a UUID in string format
+	count  :  number of cards to look at
+returns:
+	the top cards, top card first
+	error if deck not found or has fewer cards
+*/
+func (s *Service) Peek(deckId string, count int) ([]Card, error) {
+	var peeked []Card
+	if count <= 0 {
+		return peeked, newError(ErrInvalidArgument, "count must be more than zero")
+	}
+	deck, e := s.OpenDeck(deckId)
+	if e != nil {
+		return nil, e
+	}
+	if count > len(deck.Cards) {
+		message := fmt.Sprintf("cannot peek at %d cards, deck has only %d", count, len(deck.Cards))
+		return nil, newError(ErrInsufficientCards, message)
+	}
+	return cloneCards(deck.Cards[:count]), nil
+}
+
+/*
+Cuts the deck, i.e. moves the top cards below the remaining cards
+inputs:
+	deckId   :  a UUID in string format
+	position :  number of cards moved from top to bottom, a random position if 0
+returns:
+	the updated deck
+	error if deck not found, is closed or position is not between 1 and
+	the number of cards less one
+*/
+func (s *Service) Cut(deckId string, position int) (Deck, error) {
+	if position < 0 {
+		return Deck{}, newError(ErrInvalidArgument, "position must not be negative")
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot cut, deck is closed")
+		}
+		if len(deck.Cards) < 2 {
+			return newError(ErrInsufficientCards, "cannot cut a deck with less than 2 cards")
+		}
+		at := position
+		if at == 0 {
+			s.randomMu.Lock()
+			at = 1 + s.random.Intn(len(deck.Cards)-1)
+			s.randomMu.Unlock()
+		}
+		if at >= len(deck.Cards) {
+			message := fmt.Sprintf("cannot cut at %d, deck has only %d cards", at, len(deck.Cards))
+			return newError(ErrInsufficientCards, message)
+		}
+		cutCards(deck.Cards, at)
+		deck.record(Event{Type: EventCut, CutAt: at})
+		return nil
+	})
+}
+
+/*
+Burns the top cards of the deck, i.e. places them face down on the burn pile
+inputs:
+	deckId :  a UUID in string format
+	count  :  number of cards to burn
+returns:
+	the burned cards
+	the updated deck
+	error if deck not found, is closed or has fewer cards
+*/
+func (s *Service) Burn(deckId string, count int) ([]Card, Deck, error) {
+	var burned []Card
+	if count <= 0 {
+		return burned, Deck{}, newError(ErrInvalidArgument, "count must be more than zero")
+	}
+	d, e := s.mutate(deckId, func(deck *Deck) error {
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot burn any cards, deck is closed")
+		}
+		if count > len(deck.Cards) {
+			message := fmt.Sprintf("cannot burn %d cards, deck has only %d", count, len(deck.Cards))
+			return newError(ErrInsufficientCards, message)
+		}
+		burned, deck.Cards = cloneCards(deck.Cards[:count]), deck.Cards[count:]
+		deck.placeOnPile(BurnPile, burned)
+		deck.record(Event{Type: EventBurn, Cards: burned, Pile: BurnPile})
+		return nil
+	})
+	if e != nil {
+		return nil, d, e
+	}
+	return burned, d, nil
+}
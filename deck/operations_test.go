@@ -0,0 +1,102 @@
+package deck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeek(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C")
+	deck_id := d.DeckId.String()
+
+	peeked, error := service.Peek(deck_id, 2)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "KD"}, cardCodes(peeked))
+
+	// peeking does not change the deck, its history or its version
+	deck, _ := service.OpenDeck(deck_id)
+	assert.Equal(t, d.Cards, deck.Cards)
+	assert.Equal(t, d.History, deck.History)
+	assert.Equal(t, d.Version, deck.Version)
+
+	_, error = service.Peek(deck_id, 5)
+	assert.NotNil(t, error)
+	_, error = service.Peek(deck_id, 0)
+	assert.NotNil(t, error)
+}
+
+func TestCut(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C")
+	deck_id := d.DeckId.String()
+
+	deck, error := service.Cut(deck_id, 1)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"KD", "AC", "2C", "AS"}, cardCodes(deck.Cards))
+	last := deck.History[len(deck.History)-1]
+	assert.False(t, last.Timestamp.IsZero())
+	last.Timestamp = time.Time{}
+	assert.Equal(t, Event{Type: EventCut, CutAt: 1}, last)
+
+	// a random cut moves at least one card and keeps the cyclic order
+	deck, error = service.Cut(deck_id, 0)
+	assert.Nil(t, error)
+	at := deck.History[len(deck.History)-1].CutAt
+	assert.True(t, at >= 1 && at <= 3)
+	assert.Equal(t, 4, len(deck.Cards))
+
+	_, error = service.Cut(deck_id, 4)
+	assert.NotNil(t, error)
+	_, error = service.Cut(deck_id, -1)
+	assert.NotNil(t, error)
+}
+
+func TestBurn(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C")
+	deck_id := d.DeckId.String()
+
+	burned, deck, error := service.Burn(deck_id, 1)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS"}, cardCodes(burned))
+	assert.Equal(t, 3, len(deck.Cards))
+
+	_, deck, error = service.Burn(deck_id, 2)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"KD", "AC", "AS"}, cardCodes(deck.Piles[BurnPile]))
+
+	_, _, error = service.Burn(deck_id, 2)
+	assert.NotNil(t, error)
+	service.CloseDeck(deck_id)
+	_, _, error = service.Burn(deck_id, 1)
+	assert.NotNil(t, error)
+	_, error = service.Cut(deck_id, 1)
+	assert.NotNil(t, error)
+}
+
+func TestEveryOperationIsRecorded(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+
+	service.Cut(deck_id, 1)
+	service.Burn(deck_id, 1)
+	service.DrawCards(deck_id, 2)
+	service.AddToPile(deck_id, "player1", "2C")
+	service.MoveCards(deck_id, "player1", "table", "")
+	service.DrawFromPile(deck_id, "table", 1, FromTop)
+	service.ReturnCards(deck_id, "2C", FromRandom)
+	service.ReturnPile(deck_id, BurnPile, FromBottom)
+	service.ReshuffleRemaining(deck_id, "", 0)
+	service.CloseDeck(deck_id)
+
+	deck, _ := service.OpenDeck(deck_id)
+	types := []string{}
+	for _, event := range deck.History {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{EventCreate, EventCut, EventBurn, EventDraw, EventAddToPile,
+		EventMoveCards, EventDrawFromPile, EventReturnCards, EventReturnPile, EventReshuffle, EventClose}, types)
+	assert.Equal(t, d.Cards, deck.History[0].Cards)
+	assert.Equal(t, 1, len(deck.History[7].Indices))
+	assert.Equal(t, deck.Cards, deck.History[9].Cards)
+}
@@ -0,0 +1,78 @@
+package deck
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maximum number of decks of a page, see ListDecksPage
+const MaxDecksPage = 100
+
+/*
+Returns a page of the decks, oldest first. The cursor points after the last
+deck of a page, so that decks which are created or deleted in between do
+not shift the following pages
+inputs:
+	limit  :  maximum number of decks, from 1 to MaxDecksPage
+	cursor :  cursor returned with the previous page, empty for the first page
+returns:
+	the decks of the page
+	cursor of the next page, empty if there are no more decks
+	error if limit or cursor is invalid or the decks cannot be read from the store
+*/
+func (s *Service) ListDecksPage(limit int, cursor string) ([]Deck, string, error) {
+	if limit < 1 || limit > MaxDecksPage {
+		message := fmt.Sprintf("limit must be from 1 to %d, not %d", MaxDecksPage, limit)
+		return nil, "", newError(ErrInvalidArgument, message)
+	}
+	var after *ListPosition
+	if len(cursor) > 0 {
+		position, e := parseCursor(cursor)
+		if e != nil {
+			return nil, "", e
+		}
+		after = &position
+	}
+	// one more deck tells whether there is a next page
+	decks, e := s.store.ListPage(limit+1, after)
+	if e != nil {
+		return nil, "", e
+	}
+	if len(decks) <= limit {
+		return decks, "", nil
+	}
+	return decks[:limit], newCursor(decks[limit-1]), nil
+}
+
+// returns the cursor which points after the deck, i.e. its creation time and id
+func newCursor(d Deck) string {
+	position := fmt.Sprintf("%d:%v", d.CreatedAt.UnixNano(), d.DeckId)
+	return base64.RawURLEncoding.EncodeToString([]byte(position))
+}
+
+// returns the position of the deck the cursor points after
+func parseCursor(cursor string) (ListPosition, error) {
+	message := fmt.Sprintf("cursor '%v' is not valid", cursor)
+	position, e := base64.RawURLEncoding.DecodeString(cursor)
+	if e != nil {
+		return ListPosition{}, newError(ErrInvalidArgument, message)
+	}
+	parts := strings.SplitN(string(position), ":", 2)
+	if len(parts) != 2 {
+		return ListPosition{}, newError(ErrInvalidArgument, message)
+	}
+	nanoseconds, e := strconv.ParseInt(parts[0], 10, 64)
+	if e != nil {
+		return ListPosition{}, newError(ErrInvalidArgument, message)
+	}
+	id, e := uuid.Parse(parts[1])
+	if e != nil {
+		return ListPosition{}, newError(ErrInvalidArgument, message)
+	}
+	return ListPosition{CreatedAt: time.Unix(0, nanoseconds).UTC(), DeckId: id}, nil
+}
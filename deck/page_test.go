@@ -0,0 +1,55 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListDecksPage(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	ids := []string{}
+	for i := 0; i < 5; i++ {
+		d, _ := s.CreateNewDeck(false, "AS")
+		ids = append(ids, d.DeckId.String())
+	}
+
+	decks, cursor, error := s.ListDecksPage(2, "")
+	assert.Nil(t, error)
+	assert.Equal(t, ids[:2], deckIds(decks))
+	assert.NotEmpty(t, cursor)
+
+	// a deleted deck does not shift the following pages
+	s.DeleteDeck(ids[1])
+	s.DeleteDeck(ids[2])
+	decks, cursor, error = s.ListDecksPage(2, cursor)
+	assert.Nil(t, error)
+	assert.Equal(t, ids[3:], deckIds(decks))
+	assert.Empty(t, cursor)
+
+	decks, cursor, _ = s.ListDecksPage(MaxDecksPage, "")
+	assert.Equal(t, 3, len(decks))
+	assert.Empty(t, cursor)
+}
+
+func TestListDecksPageInvalid(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	for _, limit := range []int{0, -1, MaxDecksPage + 1} {
+		_, _, error := s.ListDecksPage(limit, "")
+		assert.True(t, errors.Is(error, ErrInvalidArgument))
+	}
+	for _, cursor := range []string{"%%", "bm8tY29sb24", "eDpub3QtYS11dWlk", "MTI6bm90LWEtdXVpZA"} {
+		_, _, error := s.ListDecksPage(1, cursor)
+		assert.True(t, errors.Is(error, ErrInvalidArgument), cursor)
+	}
+}
+
+// returns the ids of the decks
+func deckIds(decks []Deck) []string {
+	ids := []string{}
+	for _, d := range decks {
+		ids = append(ids, d.DeckId.String())
+	}
+	return ids
+}
@@ -0,0 +1,273 @@
+package deck
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// positions from which cards are drawn
+const (
+	FromTop    = "top"
+	FromBottom = "bottom"
+	FromRandom = "random"
+)
+
+// names of piles are short words of letters, digits, - and _
+var pileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+/*
+Moves drawn cards onto the top of a pile, the pile is created if needed.
+The cards are placed as a packet, so the first code is the new top card
+inputs:
+	deckId :  a UUID in string format
+	pile   :  name of the pile, e.g. discard or player1
+	codes  :  comma separated list of codes of drawn cards
+returns:
+	the updated deck
+	error if deck not found, pile name is invalid or any card was not drawn
+*/
+func (s *Service) AddToPile(deckId string, pile string, codes string) (Deck, error) {
+	if e := validatePileName(pile); e != nil {
+		return Deck{}, e
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		cards, drawn, e := takeCards(deck.Drawn, deck.parseCodes(codes))
+		if e != nil {
+			return fmt.Errorf("cannot add cards to pile %v: %w", pile, e)
+		}
+		deck.Drawn = drawn
+		deck.placeOnPile(pile, cards)
+		deck.record(Event{Type: EventAddToPile, Cards: cards, Pile: pile})
+		return nil
+	})
+}
+
+/*
+Returns the cards of a pile, top card first
+inputs:
+	deckId :  a UUID in string format
+	pile   :  name of the pile
+returns:
+	cards of the pile
+	error if deck or pile not found
+*/
+func (s *Service) Pile(deckId string, pile string) ([]Card, error) {
+	deck, e := s.OpenDeck(deckId)
+	if e != nil {
+		return nil, e
+	}
+	cards, exists := deck.Piles[pile]
+	if !exists {
+		message := fmt.Sprintf("pile %v not found in deck %v", pile, deckId)
+		return nil, newError(ErrPileNotFound, message)
+	}
+	return cards, nil
+}
+
+/*
+Draws cards from a pile. Drawn cards leave the pile, they can be added to
+another pile with AddToPile
+inputs:
+	deckId :  a UUID in string format
+	pile   :  name of the pile
+	count  :  number of cards to draw
+	from   :  top, bottom or random, top if empty
+returns:
+	the drawn cards
+	error if deck or pile not found, from is invalid or the pile has fewer cards
+*/
+func (s *Service) DrawFromPile(deckId string, pile string, count int, from string) ([]Card, error) {
+	var hand []Card
+	if count <= 0 {
+		return hand, newError(ErrInvalidArgument, "count must be more than zero")
+	}
+	from, e := normalizeFrom(from)
+	if e != nil {
+		return hand, e
+	}
+	_, e = s.mutate(deckId, func(deck *Deck) error {
+		cards, exists := deck.Piles[pile]
+		if !exists {
+			message := fmt.Sprintf("pile %v not found in deck %v", pile, deckId)
+			return newError(ErrPileNotFound, message)
+		}
+		if count > len(cards) {
+			message := fmt.Sprintf("cannot draw %d cards, pile %v has only %d", count, pile, len(cards))
+			return newError(ErrInsufficientCards, message)
+		}
+		var indices []int
+		hand, deck.Piles[pile], indices = s.takeFrom(cards, count, from)
+		deck.Drawn = append(deck.Drawn, hand...)
+		deck.record(Event{Type: EventDrawFromPile, Cards: hand, Pile: pile, Position: from, Indices: indices})
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return hand, nil
+}
+
+/*
+Moves cards from one pile onto the top of another, which is created if needed
+inputs:
+	deckId :  a UUID in string format
+	from   :  name of the pile the cards are taken from
+	to     :  name of the pile the cards are placed on
+	codes  :  comma separated list of codes of cards to move, all cards of the pile if empty
+returns:
+	the updated deck
+	error if deck or pile not found, pile name is invalid or any card is not in the pile
+*/
+func (s *Service) MoveCards(deckId string, from string, to string, codes string) (Deck, error) {
+	if e := validatePileName(to); e != nil {
+		return Deck{}, e
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		cards, exists := deck.Piles[from]
+		if !exists {
+			message := fmt.Sprintf("pile %v not found in deck %v", from, deckId)
+			return newError(ErrPileNotFound, message)
+		}
+		moved := cards
+		rest := []Card{}
+		if len(strings.TrimSpace(codes)) > 0 {
+			var e error
+			moved, rest, e = takeCards(cards, deck.parseCodes(codes))
+			if e != nil {
+				return fmt.Errorf("cannot move cards from pile %v: %w", from, e)
+			}
+		}
+		deck.Piles[from] = rest
+		deck.placeOnPile(to, moved)
+		deck.record(Event{Type: EventMoveCards, Cards: moved, Pile: from, To: to})
+		return nil
+	})
+}
+
+// places the cards as a packet on top of the pile, creating the pile if needed
+func (d *Deck) placeOnPile(pile string, cards []Card) {
+	if d.Piles == nil {
+		d.Piles = map[string][]Card{}
+	}
+	d.Piles[pile] = append(append([]Card{}, cards...), d.Piles[pile]...)
+}
+
+/*
+Returns the codes of a comma separated list in canonical form. Codes of
+standard cards are normalized as in ParseCard, invalid codes are kept as
+they are so that they are reported as missing
+*/
+func (d Deck) parseCodes(codes string) []string {
+	parsed := []string{}
+	for _, code := range strings.Split(codes, ",") {
+		code = strings.TrimSpace(code)
+		if len(d.CardSet) == 0 {
+			if card, e := ParseCard(code); e == nil {
+				code = card.Code()
+			}
+		}
+		parsed = append(parsed, code)
+	}
+	return parsed
+}
+
+/*
+Takes one card for each code out of cards. A code given twice takes two
+identical cards, as they are in a shoe
+returns:
+	the taken cards in the order of the codes
+	the remaining cards in their order
+	error if any card is not in cards
+*/
+func takeCards(cards []Card, codes []string) ([]Card, []Card, error) {
+	rest := append([]Card{}, cards...)
+	taken := []Card{}
+	for _, code := range codes {
+		index := -1
+		for i, card := range rest {
+			if card.Code() == code {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			message := fmt.Sprintf("card %v is not available", code)
+			return nil, cards, newError(ErrCardNotAvailable, message)
+		}
+		taken = append(taken, rest[index])
+		rest = append(rest[:index], rest[index+1:]...)
+	}
+	return taken, rest, nil
+}
+
+/*
+Takes count cards from the top, bottom or random positions of cards. Cards
+from the bottom are returned bottom card first
+returns:
+	the taken cards
+	the remaining cards
+	for random positions the index of each card when it was taken, see takeAt
+*/
+func (s *Service) takeFrom(cards []Card, count int, from string) ([]Card, []Card, []int) {
+	var indices []int
+	if from == FromRandom {
+		indices = make([]int, count)
+		s.randomMu.Lock()
+		for i := range indices {
+			indices[i] = s.random.Intn(len(cards) - i)
+		}
+		s.randomMu.Unlock()
+	}
+	taken, rest := takeAt(cards, count, from, indices)
+	return taken, rest, indices
+}
+
+/*
+Takes count cards from the top or the bottom of cards, or for random
+positions one after another each at its index
+returns:
+	the taken cards
+	the remaining cards
+*/
+func takeAt(cards []Card, count int, from string, indices []int) ([]Card, []Card) {
+	rest := cloneCards(cards)
+	switch from {
+	case FromBottom:
+		taken := make([]Card, count)
+		for i := 0; i < count; i++ {
+			taken[i] = rest[len(rest)-1-i]
+		}
+		return taken, rest[:len(rest)-count]
+	case FromRandom:
+		taken := make([]Card, count)
+		for i, index := range indices {
+			taken[i] = rest[index]
+			rest = append(rest[:index], rest[index+1:]...)
+		}
+		return taken, rest
+	}
+	return rest[:count], rest[count:]
+}
+
+// returns from in lower case, top if empty, or error if it is not a known position
+func normalizeFrom(from string) (string, error) {
+	from = strings.ToLower(strings.TrimSpace(from))
+	switch from {
+	case "":
+		return FromTop, nil
+	case FromTop, FromBottom, FromRandom:
+		return from, nil
+	}
+	message := fmt.Sprintf("'%v' is invalid, should be one of %v, %v or %v", from, FromTop, FromBottom, FromRandom)
+	return "", newError(ErrInvalidArgument, message)
+}
+
+// returns error if name is not a valid pile name
+func validatePileName(name string) error {
+	if !pileNamePattern.MatchString(name) {
+		message := fmt.Sprintf("pile name '%v' is invalid, should have up to 64 letters, digits, - or _", name)
+		return newError(ErrInvalidArgument, message)
+	}
+	return nil
+}
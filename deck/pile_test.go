@@ -0,0 +1,118 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddToPile(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 4)
+
+	deck, error := service.AddToPile(deck_id, "discard", "AS,kd")
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "KD"}, cardCodes(deck.Piles["discard"]))
+	assert.Equal(t, []string{"AC", "2C"}, cardCodes(deck.Drawn))
+
+	// new cards are placed on top
+	deck, error = service.AddToPile(deck_id, "discard", "2C")
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"2C", "AS", "KD"}, cardCodes(deck.Piles["discard"]))
+
+	pile, error := service.Pile(deck_id, "discard")
+	assert.Nil(t, error)
+	assert.Equal(t, deck.Piles["discard"], pile)
+}
+
+func TestAddToPileInvalid(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 1)
+
+	// cards still in the deck or already in a pile cannot be added
+	_, error := service.AddToPile(deck_id, "discard", "KD")
+	assert.NotNil(t, error)
+	service.AddToPile(deck_id, "discard", "AS")
+	_, error = service.AddToPile(deck_id, "table", "AS")
+	assert.NotNil(t, error)
+
+	_, error = service.AddToPile(deck_id, "my pile", "AS")
+	assert.NotNil(t, error)
+	_, error = service.Pile(deck_id, "table")
+	assert.NotNil(t, error)
+}
+
+func TestPileWithDuplicateCards(t *testing.T) {
+	d, _ := service.CreateDeck(DeckOptions{Codes: "AS,KD", DecksCount: 2})
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 3)
+
+	// both aces of the shoe can be added, a third one does not exist
+	deck, error := service.AddToPile(deck_id, "table", "AS,AS")
+	assert.Nil(t, error)
+	assert.Equal(t, 2, len(deck.Piles["table"]))
+	_, error = service.AddToPile(deck_id, "table", "AS")
+	assert.NotNil(t, error)
+}
+
+func TestDrawFromPile(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,2S,3S,4S,5S,6S")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 6)
+	service.AddToPile(deck_id, "stock", "AS,2S,3S,4S,5S,6S")
+
+	hand, error := service.DrawFromPile(deck_id, "stock", 2, "")
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "2S"}, cardCodes(hand))
+
+	hand, error = service.DrawFromPile(deck_id, "stock", 1, FromBottom)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"6S"}, cardCodes(hand))
+
+	hand, error = service.DrawFromPile(deck_id, "stock", 2, "Random")
+	assert.Nil(t, error)
+	assert.Equal(t, 2, len(hand))
+
+	pile, _ := service.Pile(deck_id, "stock")
+	assert.Equal(t, 1, len(pile))
+	deck, _ := service.OpenDeck(deck_id)
+	assert.Equal(t, 5, len(deck.Drawn))
+	assertDealtExactlyOnce(t, d.Cards, [][]Card{deck.Drawn, pile})
+
+	_, error = service.DrawFromPile(deck_id, "stock", 2, FromTop)
+	assert.NotNil(t, error)
+	_, error = service.DrawFromPile(deck_id, "stock", 1, "middle")
+	assert.NotNil(t, error)
+	_, error = service.DrawFromPile(deck_id, "stock", 0, FromTop)
+	assert.NotNil(t, error)
+	_, error = service.DrawFromPile(deck_id, "unknown", 1, FromTop)
+	assert.NotNil(t, error)
+}
+
+func TestMoveCards(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 4)
+	service.AddToPile(deck_id, "player1", "AS,KD,AC")
+	service.AddToPile(deck_id, "table", "2C")
+
+	deck, error := service.MoveCards(deck_id, "player1", "table", "KD")
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"AS", "AC"}, cardCodes(deck.Piles["player1"]))
+	assert.Equal(t, []string{"KD", "2C"}, cardCodes(deck.Piles["table"]))
+
+	// without codes the whole pile is moved
+	deck, error = service.MoveCards(deck_id, "table", "discard", "")
+	assert.Nil(t, error)
+	assert.Equal(t, 0, len(deck.Piles["table"]))
+	assert.Equal(t, []string{"KD", "2C"}, cardCodes(deck.Piles["discard"]))
+
+	_, error = service.MoveCards(deck_id, "player1", "discard", "KD")
+	assert.NotNil(t, error)
+	_, error = service.MoveCards(deck_id, "unknown", "discard", "")
+	assert.NotNil(t, error)
+	_, error = service.MoveCards(deck_id, "player1", "", "")
+	assert.NotNil(t, error)
+}
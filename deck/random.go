@@ -0,0 +1,50 @@
+package deck
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+)
+
+/*
+CryptoSource is a math/rand source which reads from crypto/rand, so shuffles
+cannot be predicted from earlier shuffles or from the time. It cannot be
+seeded, Seed is a no-op. This is the default source of a Service.
+*/
+type CryptoSource struct{}
+
+var _ rand.Source64 = CryptoSource{}
+
+func (CryptoSource) Int63() int64 {
+	return int64(CryptoSource{}.Uint64() >> 1)
+}
+
+func (CryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// the operating system random generator is not expected to ever fail
+		panic("deck: cannot read from crypto/rand: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (CryptoSource) Seed(int64) {}
+
+// option to configure a Service
+type ServiceOption func(s *Service)
+
+/*
+Returns an option which makes the service use given source of randomness for
+shuffles which do not have their own seed. Use rand.NewSource(seed) for a
+reproducible sequence of shuffles, for example in tests
+*/
+func WithRandomSource(source rand.Source) ServiceOption {
+	return func(s *Service) {
+		s.random = rand.New(source)
+	}
+}
+
+// returns a generator which always produces the same sequence for the seed
+func seededRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
@@ -0,0 +1,61 @@
+package deck
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCryptoSource(t *testing.T) {
+	r := rand.New(CryptoSource{})
+	seen := map[int64]bool{}
+	for i := 0; i < 100; i++ {
+		n := r.Int63()
+		assert.GreaterOrEqual(t, n, int64(0))
+		seen[n] = true
+	}
+	assert.Equal(t, 100, len(seen))
+}
+
+func TestSeededDeckIsReproducible(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	seed := int64(20221030)
+
+	for _, method := range []string{"fisher-yates", "riffle", "overhand", "pile"} {
+		options := DeckOptions{Shuffle: true, ShuffleMethod: method, Seed: &seed}
+		first, error := s.CreateDeck(options)
+		assert.Nil(t, error)
+		second, _ := s.CreateDeck(options)
+
+		assert.NotEqual(t, first.DeckId, second.DeckId)
+		assert.Equal(t, first.Cards, second.Cards, method)
+		assert.Equal(t, seed, *first.Seed)
+		assert.Equal(t, method, first.ShuffleMethod)
+		assert.Nil(t, first.Proof)
+
+		// seed is stored with the deck
+		opened, _ := s.OpenDeck(first.DeckId.String())
+		assert.Equal(t, seed, *opened.Seed)
+	}
+}
+
+func TestSeedIgnoredWithoutShuffle(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	seed := int64(1)
+	d, _ := s.CreateDeck(DeckOptions{Seed: &seed})
+	assert.Nil(t, d.Seed)
+	assert.Equal(t, "AS", d.Cards[0].Code())
+}
+
+func TestServiceWithRandomSource(t *testing.T) {
+	first := NewService(NewMemoryStore(), WithRandomSource(rand.NewSource(3)))
+	second := NewService(NewMemoryStore(), WithRandomSource(rand.NewSource(3)))
+
+	for i := 0; i < 3; i++ {
+		d1, _ := first.CreateNewDeck(true, "")
+		d2, _ := second.CreateNewDeck(true, "")
+		assert.Equal(t, d1.Cards, d2.Cards)
+		assert.Nil(t, d1.Seed)
+	}
+}
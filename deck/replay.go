@@ -0,0 +1,315 @@
+package deck
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+Returns the history of a deck, i.e. every operation on it oldest first
+inputs:
+	deckId :  a UUID in string format
+returns:
+	the events of the deck
+	error if UUID is not valid or deck not found
+*/
+func (s *Service) History(deckId string) ([]Event, error) {
+	deck, e := s.OpenDeck(deckId)
+	if e != nil {
+		return nil, e
+	}
+	return deck.History, nil
+}
+
+/*
+Returns the state of a stored deck right after the event at index, see Replay
+inputs:
+	deckId :  a UUID in string format
+	index  :  index of the event in the history, starting at 0 for create
+returns:
+	the deck as it was after the event
+	error if deck not found, index is out of range or the history is inconsistent
+*/
+func (s *Service) ReplayDeck(deckId string, index int) (Deck, error) {
+	deck, e := s.OpenDeck(deckId)
+	if e != nil {
+		return Deck{}, e
+	}
+	return Replay(deck, index)
+}
+
+/*
+Rebuilds the deck as it was right after the event at index by applying the
+events of its history in order to an empty deck. Attributes which are set
+when a deck is created, e.g. its id, type, size and shuffle proof, and its
+snapshots are kept from the given deck. Cards taken by every event are checked against the
+cards recorded in the event. An undo or redo rebuilds the deck from the
+operations in effect after it, and the events reverted at index are marked
+as reverted. A restore rebuilds the deck as it was when the snapshot was saved
+inputs:
+	d     :  deck with its history
+	index :  index of the event in the history, starting at 0 for create
+returns:
+	the deck with the cards, drawn cards, piles and history it had after the event
+	error if index is out of range or an event cannot be applied
+*/
+func Replay(d Deck, index int) (Deck, error) {
+	if index < 0 || index >= len(d.History) {
+		message := fmt.Sprintf("event index %d is invalid, deck has %d events", index, len(d.History))
+		return Deck{}, newError(ErrInvalidArgument, message)
+	}
+	replayed := d.emptied()
+	// whether each operation is in effect, i.e. applied and not reverted
+	inEffect := make([]bool, index+1)
+	for i, event := range d.History[:index+1] {
+		event.Reverted = false
+		replayed.History = append(replayed.History, event)
+		if e := replayed.replayEvent(d, i, inEffect); e != nil {
+			message := fmt.Sprintf("cannot replay event %d (%v): %v", i, event.Type, e)
+			return Deck{}, newError(ErrInvalidHistory, message)
+		}
+	}
+	return replayed.clone(), nil
+}
+
+// returns the deck without cards, drawn cards, piles and history, as it was before its creation
+func (d Deck) emptied() Deck {
+	d.Cards = []Card{}
+	d.Drawn = nil
+	d.Piles = nil
+	d.Shuffled = false
+	d.Closed = false
+	d.History = nil
+	return d
+}
+
+// applies the event at index of the history of d while replaying it, see Replay
+func (d *Deck) replayEvent(history Deck, index int, inEffect []bool) error {
+	event := history.History[index]
+	if event.Type != EventUndo && event.Type != EventRedo {
+		inEffect[index] = true
+		return d.applyAt(history, index)
+	}
+	for _, i := range event.Events {
+		if i < 0 || i >= index || !history.History[i].isOperation() {
+			message := fmt.Sprintf("event %d cannot be reverted or applied again", i)
+			return errors.New(message)
+		}
+		inEffect[i] = event.Type == EventRedo
+		d.History[i].Reverted = !inEffect[i]
+	}
+
+	rebuilt := history.emptied()
+	for i, applied := range inEffect[:index] {
+		if !applied {
+			continue
+		}
+		if e := rebuilt.applyAt(history, i); e != nil {
+			return e
+		}
+	}
+	d.setState(rebuilt)
+	return nil
+}
+
+// applies the event at index of the history of d, a restore sets the state the deck had when the snapshot was saved
+func (d *Deck) applyAt(history Deck, index int) error {
+	event := history.History[index]
+	if event.Type != EventRestore {
+		return d.apply(event)
+	}
+	if len(event.Events) != 1 || event.Events[0] < 0 || event.Events[0] >= index ||
+		history.History[event.Events[0]].Type != EventSnapshot {
+		message := fmt.Sprintf("restore of snapshot %v does not refer to the event which saved it", event.Snapshot)
+		return errors.New(message)
+	}
+	restored, e := Replay(history, event.Events[0])
+	if e != nil {
+		return e
+	}
+	d.setState(restored)
+	return nil
+}
+
+// sets the cards, drawn cards and piles of the deck to those of another deck
+func (d *Deck) setState(from Deck) {
+	d.Cards, d.Drawn, d.Piles = from.Cards, from.Drawn, from.Piles
+	d.Shuffled, d.Closed = from.Shuffled, from.Closed
+}
+
+// applies a recorded event to the deck, the opposite of recording it
+func (d *Deck) apply(event Event) error {
+	count := len(event.Cards)
+	switch event.Type {
+	case EventCreate:
+		d.Cards = cloneCards(event.Cards)
+	case EventShuffle, EventReshuffle:
+		if count != len(d.Cards) {
+			message := fmt.Sprintf("shuffled %d cards, deck has %d", count, len(d.Cards))
+			return errors.New(message)
+		}
+		d.Cards = cloneCards(event.Cards)
+		d.Shuffled = true
+	case EventDraw:
+		if len(event.Position) == 0 {
+			taken, rest, e := takeCards(d.Cards, cardCodes(event.Cards))
+			if e != nil {
+				return e
+			}
+			d.Cards = rest
+			d.Drawn = append(d.Drawn, taken...)
+			return nil
+		}
+		taken, rest, e := takeRecorded(d.Cards, event)
+		if e != nil {
+			return e
+		}
+		d.Cards = rest
+		d.Drawn = append(d.Drawn, taken...)
+	case EventPeek:
+		if count > len(d.Cards) || !equalCards(d.Cards[:count], event.Cards) {
+			return errors.New("peeked cards are not on top of the deck")
+		}
+	case EventCut:
+		if event.CutAt < 1 || event.CutAt >= len(d.Cards) {
+			message := fmt.Sprintf("cannot cut at %d, deck has %d cards", event.CutAt, len(d.Cards))
+			return errors.New(message)
+		}
+		cutCards(d.Cards, event.CutAt)
+	case EventBurn, EventDeal:
+		if count > len(d.Cards) || !equalCards(d.Cards[:count], event.Cards) {
+			return errors.New("cards are not on top of the deck")
+		}
+		d.Cards = d.Cards[count:]
+		if event.Type == EventBurn {
+			d.placeOnPile(event.Pile, event.Cards)
+			return nil
+		}
+		if event.Players <= 0 {
+			return errors.New("deal has no players")
+		}
+		for i, hand := range splitHands(event.Cards, event.Players, event.Order) {
+			d.placeOnPile(PlayerPile(i+1), hand)
+		}
+	case EventAddToPile:
+		taken, drawn, e := takeCards(d.Drawn, cardCodes(event.Cards))
+		if e != nil {
+			return e
+		}
+		d.Drawn = drawn
+		d.placeOnPile(event.Pile, taken)
+	case EventDrawFromPile:
+		cards, exists := d.Piles[event.Pile]
+		if !exists {
+			return fmt.Errorf("pile %v not found", event.Pile)
+		}
+		taken, rest, e := takeRecorded(cards, event)
+		if e != nil {
+			return e
+		}
+		d.Piles[event.Pile] = rest
+		d.Drawn = append(d.Drawn, taken...)
+	case EventMoveCards:
+		cards, exists := d.Piles[event.Pile]
+		if !exists {
+			return fmt.Errorf("pile %v not found", event.Pile)
+		}
+		moved, rest, e := takeCards(cards, cardCodes(event.Cards))
+		if e != nil {
+			return e
+		}
+		d.Piles[event.Pile] = rest
+		d.placeOnPile(event.To, moved)
+	case EventReturnCards:
+		returned, drawn, e := takeCards(d.Drawn, cardCodes(event.Cards))
+		if e != nil {
+			return e
+		}
+		if e := validateInsertIndices(event, len(d.Cards)); e != nil {
+			return e
+		}
+		d.Drawn = drawn
+		d.Cards = insertAt(d.Cards, returned, event.Position, event.Indices)
+	case EventReturnPile:
+		cards, exists := d.Piles[event.Pile]
+		if !exists || !equalCards(cards, event.Cards) {
+			return fmt.Errorf("pile %v does not hold the returned cards", event.Pile)
+		}
+		if e := validateInsertIndices(event, len(d.Cards)); e != nil {
+			return e
+		}
+		delete(d.Piles, event.Pile)
+		d.Cards = insertAt(d.Cards, cards, event.Position, event.Indices)
+	case EventClose:
+		d.Closed = true
+	case EventClone, EventSnapshot:
+		// the cards are not changed
+	default:
+		message := fmt.Sprintf("event type '%v' is unknown", event.Type)
+		return errors.New(message)
+	}
+	return nil
+}
+
+/*
+Takes the cards of a draw event from the top, bottom or the recorded random
+positions of cards
+returns:
+	the taken cards
+	the remaining cards
+	error if cards has fewer cards, an index is out of range or the taken
+	cards differ from the cards of the event
+*/
+func takeRecorded(cards []Card, event Event) ([]Card, []Card, error) {
+	count := len(event.Cards)
+	if count > len(cards) {
+		message := fmt.Sprintf("cannot take %d cards, only %d left", count, len(cards))
+		return nil, cards, errors.New(message)
+	}
+	if event.Position == FromRandom {
+		if len(event.Indices) != count {
+			return nil, cards, errors.New("random positions are not recorded for every card")
+		}
+		for i, index := range event.Indices {
+			if index < 0 || index >= len(cards)-i {
+				message := fmt.Sprintf("random position %d is out of range", index)
+				return nil, cards, errors.New(message)
+			}
+		}
+	}
+	taken, rest := takeAt(cards, count, event.Position, event.Indices)
+	if !equalCards(taken, event.Cards) {
+		return nil, cards, errors.New("taken cards differ from the recorded cards")
+	}
+	return taken, rest, nil
+}
+
+// returns error if the random positions of a return event do not fit a deck of the given size
+func validateInsertIndices(event Event, size int) error {
+	if event.Position != FromRandom {
+		return nil
+	}
+	if len(event.Indices) != len(event.Cards) {
+		return errors.New("random positions are not recorded for every card")
+	}
+	for i, index := range event.Indices {
+		if index < 0 || index > size+i {
+			message := fmt.Sprintf("random position %d is out of range", index)
+			return errors.New(message)
+		}
+	}
+	return nil
+}
+
+// returns true if both slices hold equal cards in the same order
+func equalCards(a []Card, b []Card) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
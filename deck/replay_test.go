@@ -0,0 +1,127 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayRebuildsEveryState(t *testing.T) {
+	seed := int64(42)
+	d, _ := service.CreateDeck(DeckOptions{Shuffle: true, Seed: &seed, DecksCount: 2})
+	deck_id := d.DeckId.String()
+
+	operations := []func(){
+		func() { service.Cut(deck_id, 0) },
+		func() { service.Burn(deck_id, 1) },
+		func() { service.Draw(deck_id, DrawOptions{Count: 3, From: FromRandom}) },
+		func() { service.Draw(deck_id, DrawOptions{Count: 2, From: FromBottom}) },
+		func() { service.Draw(deck_id, DrawOptions{Until: "face"}) },
+		func() { service.Deal(deck_id, 3, 2, DealBlock) },
+		func() { service.MoveCards(deck_id, "player1", "table", "") },
+		func() { service.DrawFromPile(deck_id, "table", 2, FromRandom) },
+		func() { service.ReturnPile(deck_id, "player2", FromRandom) },
+		func() { service.ReshuffleRemaining(deck_id, RiffleMethod, 0) },
+		func() { service.CloseDeck(deck_id) },
+	}
+	states := []Deck{d}
+	for _, operation := range operations {
+		operation()
+		deck, _ := service.OpenDeck(deck_id)
+		states = append(states, deck)
+	}
+	final := states[len(states)-1]
+	for _, state := range states {
+		index := len(state.History) - 1
+		replayed, error := Replay(final, index)
+		assert.Nil(t, error)
+		assert.Equal(t, state.Cards, replayed.Cards, "cards after event %d", index)
+		assert.Equal(t, state.Drawn, replayed.Drawn, "drawn cards after event %d", index)
+		assert.Equal(t, state.Piles, replayed.Piles, "piles after event %d", index)
+		assert.Equal(t, state.Closed, replayed.Closed)
+		assert.Equal(t, state.Shuffled, replayed.Shuffled)
+		assert.Equal(t, state.History, replayed.History)
+	}
+
+	replayed, error := Replay(final, 0)
+	assert.Nil(t, error)
+	assert.False(t, replayed.Shuffled)
+	assert.Equal(t, newShoe(newSequentialDeck().Cards, 2), replayed.Cards)
+}
+
+func TestReplayReturnsAndCodes(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+
+	service.Draw(deck_id, DrawOptions{Codes: "KH,AS"})
+	service.AddToPile(deck_id, "discard", "AS")
+	service.ReturnCards(deck_id, "KH", FromRandom)
+	service.ReturnPile(deck_id, "discard", FromBottom)
+	deck, _ := service.OpenDeck(deck_id)
+
+	replayed, error := service.ReplayDeck(deck_id, len(deck.History)-1)
+	assert.Nil(t, error)
+	assert.Equal(t, deck.Cards, replayed.Cards)
+	assert.Equal(t, deck.Drawn, replayed.Drawn)
+	assert.Equal(t, deck.Piles, replayed.Piles)
+
+	replayed, error = service.ReplayDeck(deck_id, 1)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"KD", "AC", "2C", "10H"}, cardCodes(replayed.Cards))
+	assert.Equal(t, []string{"KH", "AS"}, cardCodes(replayed.Drawn))
+}
+
+func TestHistory(t *testing.T) {
+	seed := int64(7)
+	d, _ := service.CreateDeck(DeckOptions{Shuffle: true, Seed: &seed, ShuffleMethod: RiffleMethod})
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 1)
+
+	history, error := service.History(deck_id)
+	assert.Nil(t, error)
+	assert.Equal(t, 3, len(history))
+	assert.Equal(t, EventCreate, history[0].Type)
+	assert.Equal(t, newSequentialDeck().Cards, history[0].Cards)
+	assert.Equal(t, EventShuffle, history[1].Type)
+	assert.Equal(t, seed, *history[1].Seed)
+	assert.Equal(t, RiffleMethod, history[1].ShuffleMethod)
+	assert.Equal(t, d.Cards, history[1].Cards)
+	for i, event := range history {
+		assert.False(t, event.Timestamp.IsZero())
+		if i > 0 {
+			assert.False(t, event.Timestamp.Before(history[i-1].Timestamp))
+		}
+	}
+
+	_, error = service.History("e4b6a1a4-2a47-4fba-9c55-9b1a3c5e2d10")
+	assert.NotNil(t, error)
+}
+
+func TestReplayInvalid(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 1)
+	deck, _ := service.OpenDeck(deck_id)
+
+	_, error := Replay(deck, -1)
+	assert.NotNil(t, error)
+	_, error = Replay(deck, len(deck.History))
+	assert.NotNil(t, error)
+
+	// a draw which does not match the deck is detected
+	deck.History[1].Cards = []Card{{Rank: King, Suit: Diamonds}}
+	_, error = Replay(deck, 1)
+	assert.NotNil(t, error)
+	deck.History[1].Type = "unknown"
+	_, error = Replay(deck, 1)
+	assert.NotNil(t, error)
+
+	// an undo can only revert earlier operations
+	deck.History = []Event{deck.History[0], {Type: EventUndo, Events: []int{1}}}
+	_, error = Replay(deck, 1)
+	assert.True(t, errors.Is(error, ErrInvalidHistory))
+	deck.History[1].Events = []int{0}
+	_, error = Replay(deck, 1)
+	assert.Nil(t, error)
+}
@@ -0,0 +1,166 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Returns drawn cards to the deck
+inputs:
+	deckId   :  a UUID in string format
+	codes    :  comma separated list of codes of drawn cards
+	position :  top, bottom or random, top if empty. Cards returned to the top
+	            are placed as a packet, so the first code is the new top card
+returns:
+	the updated deck
+	error if deck not found, position is invalid or any card does not belong
+	to the deck, is already in it or is in a pile
+*/
+func (s *Service) ReturnCards(deckId string, codes string, position string) (Deck, error) {
+	position, e := normalizeFrom(position)
+	if e != nil {
+		return Deck{}, e
+	}
+	if len(strings.TrimSpace(codes)) == 0 {
+		return Deck{}, newError(ErrInvalidArgument, "no cards to return")
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		codes := deck.parseCodes(codes)
+		cards, drawn, e := takeCards(deck.Drawn, codes)
+		if e != nil {
+			return fmt.Errorf("cannot return cards: %w", deck.explainUnavailable(codes))
+		}
+		deck.Drawn = drawn
+		var indices []int
+		deck.Cards, indices = s.insertCards(deck.Cards, cards, position)
+		deck.record(Event{Type: EventReturnCards, Cards: cards, Position: position, Indices: indices})
+		return nil
+	})
+}
+
+/*
+Returns all cards of a pile to the deck, the empty pile is removed
+inputs:
+	deckId   :  a UUID in string format
+	pile     :  name of the pile
+	position :  top, bottom or random, top if empty. The top card of the pile
+	            becomes the top card of the deck when returned to the top
+returns:
+	the updated deck
+	error if deck or pile not found or position is invalid
+*/
+func (s *Service) ReturnPile(deckId string, pile string, position string) (Deck, error) {
+	position, e := normalizeFrom(position)
+	if e != nil {
+		return Deck{}, e
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		cards, exists := deck.Piles[pile]
+		if !exists {
+			message := fmt.Sprintf("pile %v not found in deck %v", pile, deckId)
+			return newError(ErrPileNotFound, message)
+		}
+		delete(deck.Piles, pile)
+		var indices []int
+		deck.Cards, indices = s.insertCards(deck.Cards, cards, position)
+		deck.record(Event{Type: EventReturnPile, Cards: cards, Pile: pile, Position: position, Indices: indices})
+		return nil
+	})
+}
+
+/*
+Shuffles the cards which remain in the deck, drawn cards and piles are not
+changed. The proof of a provably fair shuffle keeps describing the shuffle
+of the new deck, the reshuffle uses the random source of the service
+inputs:
+	deckId :  a UUID in string format
+	method :  shuffle method, see NewShuffler
+	passes :  number of passes, method default if 0
+returns:
+	the updated deck
+	error if deck not found, is closed or shuffle method is invalid
+*/
+func (s *Service) ReshuffleRemaining(deckId string, method string, passes int) (Deck, error) {
+	shuffler, e := NewShuffler(method, passes)
+	if e != nil {
+		return Deck{}, e
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot reshuffle, deck is closed")
+		}
+		s.randomMu.Lock()
+		shuffler.Shuffle(deck.Cards, s.random)
+		s.randomMu.Unlock()
+		deck.Shuffled = true
+		deck.record(Event{Type: EventReshuffle, Cards: deck.Cards, ShuffleMethod: normalizeShuffleMethod(method), ShufflePasses: passes})
+		return nil
+	})
+}
+
+/*
+Returns the deck cards with the given cards inserted at the top, the bottom
+or each at a random position. For random positions also returns the index
+at which each card was inserted, see insertAt
+*/
+func (s *Service) insertCards(deckCards []Card, cards []Card, position string) ([]Card, []int) {
+	if position != FromRandom {
+		return insertAt(deckCards, cards, position, nil), nil
+	}
+	indices := make([]int, len(cards))
+	s.randomMu.Lock()
+	for i := range cards {
+		indices[i] = s.random.Intn(len(deckCards) + i + 1)
+	}
+	s.randomMu.Unlock()
+	return insertAt(deckCards, cards, position, indices), indices
+}
+
+/*
+Returns the deck cards with the given cards inserted at the top or the bottom,
+or for random positions one after another each at its index
+*/
+func insertAt(deckCards []Card, cards []Card, position string, indices []int) []Card {
+	switch position {
+	case FromBottom:
+		return append(cloneCards(deckCards), cards...)
+	case FromRandom:
+		result := cloneCards(deckCards)
+		for i, card := range cards {
+			index := indices[i]
+			result = append(result, Card{})
+			copy(result[index+1:], result[index:])
+			result[index] = card
+		}
+		return result
+	}
+	return append(cloneCards(cards), deckCards...)
+}
+
+/*
+Returns an error which tells for the first code which is not a drawn card,
+whether it is in the deck, in a pile or does not belong to the deck
+*/
+func (d Deck) explainUnavailable(codes []string) error {
+	drawn := d.Drawn
+	for _, code := range codes {
+		var e error
+		if _, drawn, e = takeCards(drawn, []string{code}); e == nil {
+			continue
+		}
+		if _, _, e := takeCards(d.Cards, []string{code}); e == nil {
+			message := fmt.Sprintf("card %v is already in the deck", code)
+			return newError(ErrCardNotAvailable, message)
+		}
+		for name, cards := range d.Piles {
+			if _, _, e := takeCards(cards, []string{code}); e == nil {
+				message := fmt.Sprintf("card %v is in pile %v, return the pile instead", code, name)
+				return newError(ErrCardNotAvailable, message)
+			}
+		}
+		message := fmt.Sprintf("card %v does not belong to the deck", code)
+		return newError(ErrCardNotAvailable, message)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReturnCards(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 4)
+
+	deck, error := service.ReturnCards(deck_id, "KD,AS", "")
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"KD", "AS", "KH", "10H"}, cardCodes(deck.Cards))
+	assert.Equal(t, []string{"AC", "2C"}, cardCodes(deck.Drawn))
+
+	deck, error = service.ReturnCards(deck_id, "ac", FromBottom)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"KD", "AS", "KH", "10H", "AC"}, cardCodes(deck.Cards))
+
+	deck, error = service.ReturnCards(deck_id, "2C", FromRandom)
+	assert.Nil(t, error)
+	assert.Equal(t, 6, len(deck.Cards))
+	assert.Equal(t, 0, len(deck.Drawn))
+	assertDealtExactlyOnce(t, d.Cards, [][]Card{deck.Cards})
+}
+
+func TestReturnCardsInvalid(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 2)
+	service.AddToPile(deck_id, "discard", "KD")
+
+	_, error := service.ReturnCards(deck_id, "AC", FromTop)
+	assert.ErrorContains(t, error, "already in the deck")
+	_, error = service.ReturnCards(deck_id, "KD", FromTop)
+	assert.ErrorContains(t, error, "in pile discard")
+	_, error = service.ReturnCards(deck_id, "QH", FromTop)
+	assert.ErrorContains(t, error, "does not belong to the deck")
+	_, error = service.ReturnCards(deck_id, "AS,AS", FromTop)
+	assert.NotNil(t, error)
+	_, error = service.ReturnCards(deck_id, "AS", "middle")
+	assert.NotNil(t, error)
+	_, error = service.ReturnCards(deck_id, "", FromTop)
+	assert.NotNil(t, error)
+
+	// failed returns do not change the deck
+	deck, _ := service.OpenDeck(deck_id)
+	assert.Equal(t, []string{"AC"}, cardCodes(deck.Cards))
+	assert.Equal(t, []string{"AS"}, cardCodes(deck.Drawn))
+}
+
+func TestReturnPile(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD,AC,2C")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 3)
+	service.AddToPile(deck_id, "discard", "AS,KD")
+
+	deck, error := service.ReturnPile(deck_id, "discard", FromBottom)
+	assert.Nil(t, error)
+	assert.Equal(t, []string{"2C", "AS", "KD"}, cardCodes(deck.Cards))
+	_, exists := deck.Piles["discard"]
+	assert.False(t, exists)
+
+	_, error = service.ReturnPile(deck_id, "discard", FromTop)
+	assert.NotNil(t, error)
+}
+
+func TestReshuffleRemaining(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "")
+	deck_id := d.DeckId.String()
+	hand, _ := service.DrawCards(deck_id, 10)
+
+	deck, error := service.ReshuffleRemaining(deck_id, RiffleMethod, 0)
+	assert.Nil(t, error)
+	assert.True(t, deck.Shuffled)
+	assert.Equal(t, 42, len(deck.Cards))
+	assert.NotEqual(t, d.Cards[10:], deck.Cards)
+	assert.Equal(t, hand, deck.Drawn)
+	assertDealtExactlyOnce(t, d.Cards[10:], [][]Card{deck.Cards})
+
+	_, error = service.ReshuffleRemaining(deck_id, "juggle", 0)
+	assert.NotNil(t, error)
+	_, error = service.ReshuffleRemaining(deck_id, RiffleMethod, 2147483647)
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+	service.CloseDeck(deck_id)
+	_, error = service.ReshuffleRemaining(deck_id, "", 0)
+	assert.NotNil(t, error)
+}
@@ -0,0 +1,203 @@
+package deck
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// names of the supported shuffle methods
+const (
+	FisherYatesMethod = "fisher-yates"
+	RiffleMethod      = "riffle"
+	OverhandMethod    = "overhand"
+	CutMethod         = "cut"
+	PileMethod        = "pile"
+)
+
+// number of riffles after which a 52 card deck is considered random (Bayer & Diaconis)
+const defaultRifflePasses = 7
+
+// number of overhand shuffles, overhand mixes much slower than a riffle
+const defaultOverhandPasses = 10
+
+// number of piles cards are dealt into by a pile shuffle
+const defaultPileCount = 5
+
+// maximum number of passes of a shuffle, more passes only cost time
+const MaxShufflePasses = 100
+
+/*
+Shuffler reorders cards in place. All randomness must come from r, so that a
+shuffle can be reproduced by passing a generator with the same seed
+*/
+type Shuffler interface {
+	Shuffle(cards []Card, r *rand.Rand)
+}
+
+/*
+Returns the shuffler for given method name
+inputs:
+	method :  one of fisher-yates, riffle, overhand, cut or pile. fisher-yates if empty
+	passes :  number of times the shuffle is repeated, method default if zero
+returns:
+	shuffler for the method
+	error if method is not known or passes is negative or more than MaxShufflePasses
+*/
+func NewShuffler(method string, passes int) (Shuffler, error) {
+	if passes < 0 {
+		return nil, newError(ErrInvalidArgument, fmt.Sprintf("shuffle passes %d must not be negative", passes))
+	}
+	if passes > MaxShufflePasses {
+		message := fmt.Sprintf("shuffle passes %d must not be more than %d", passes, MaxShufflePasses)
+		return nil, newError(ErrInvalidArgument, message)
+	}
+	switch normalizeShuffleMethod(method) {
+	case FisherYatesMethod:
+		return FisherYates{}, nil
+	case RiffleMethod:
+		return Riffle{Passes: passes}, nil
+	case OverhandMethod:
+		return Overhand{Passes: passes}, nil
+	case CutMethod:
+		return Cut{Passes: passes}, nil
+	case PileMethod:
+		return Pile{Passes: passes}, nil
+	}
+	return nil, newError(ErrInvalidArgument, fmt.Sprintf("shuffle method '%v' is not supported", method))
+}
+
+// unbiased shuffle where every order of the cards is equally likely
+type FisherYates struct{}
+
+func (FisherYates) Shuffle(cards []Card, r *rand.Rand) {
+	for i := len(cards) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+}
+
+/*
+Riffle shuffle following the Gilbert–Shannon–Reeds model: the deck is cut
+into two packets with binomially distributed sizes, and cards are dropped
+from either packet with probability proportional to the packet size
+*/
+type Riffle struct {
+	// number of riffles, 7 if zero
+	Passes int
+}
+
+func (s Riffle) Shuffle(cards []Card, r *rand.Rand) {
+	merged := make([]Card, len(cards))
+	for pass := 0; pass < passesOrDefault(s.Passes, defaultRifflePasses); pass++ {
+		cut := 0
+		for range cards {
+			cut += r.Intn(2)
+		}
+		left, right := cards[:cut], cards[cut:]
+
+		for i := range merged {
+			if r.Intn(len(left)+len(right)) < len(left) {
+				merged[i], left = left[0], left[1:]
+			} else {
+				merged[i], right = right[0], right[1:]
+			}
+		}
+		copy(cards, merged)
+	}
+}
+
+/*
+Overhand shuffle: small packets are repeatedly slipped off the top of the
+deck onto a new pile, which reverses the order of the packets
+*/
+type Overhand struct {
+	// number of overhand shuffles, 10 if zero
+	Passes int
+}
+
+func (s Overhand) Shuffle(cards []Card, r *rand.Rand) {
+	if len(cards) < 2 {
+		return
+	}
+	// packets are a few cards each, like a human shuffle
+	maxPacket := len(cards)/8 + 1
+	shuffled := make([]Card, len(cards))
+	for pass := 0; pass < passesOrDefault(s.Passes, defaultOverhandPasses); pass++ {
+		end := len(shuffled)
+		for remaining := cards; len(remaining) > 0; {
+			size := r.Intn(maxPacket) + 1
+			if size > len(remaining) {
+				size = len(remaining)
+			}
+			copy(shuffled[end-size:end], remaining[:size])
+			remaining = remaining[size:]
+			end -= size
+		}
+		copy(cards, shuffled)
+	}
+}
+
+// cuts the deck at a random position, moving the top part below the bottom part
+type Cut struct {
+	// number of cuts, 1 if zero
+	Passes int
+}
+
+func (s Cut) Shuffle(cards []Card, r *rand.Rand) {
+	if len(cards) < 2 {
+		return
+	}
+	for pass := 0; pass < passesOrDefault(s.Passes, 1); pass++ {
+		cutCards(cards, r.Intn(len(cards)-1)+1)
+	}
+}
+
+/*
+Pile shuffle: cards are dealt one by one onto a number of piles, which are
+then stacked on each other in random order
+*/
+type Pile struct {
+	// number of pile shuffles, 1 if zero
+	Passes int
+	// number of piles, 5 if zero
+	Piles int
+}
+
+func (s Pile) Shuffle(cards []Card, r *rand.Rand) {
+	count := passesOrDefault(s.Piles, defaultPileCount)
+	for pass := 0; pass < passesOrDefault(s.Passes, 1); pass++ {
+		piles := make([][]Card, count)
+		for i, card := range cards {
+			// dealing puts every card on top of its pile
+			piles[i%count] = append([]Card{card}, piles[i%count]...)
+		}
+		position := 0
+		for _, p := range r.Perm(count) {
+			position += copy(cards[position:], piles[p])
+		}
+	}
+}
+
+// returns the shuffle method name in lower case, fisher-yates if empty
+func normalizeShuffleMethod(method string) string {
+	method = strings.ToLower(strings.TrimSpace(method))
+	if len(method) == 0 {
+		return FisherYatesMethod
+	}
+	return method
+}
+
+// moves the top position cards below the remaining cards
+func cutCards(cards []Card, position int) {
+	top := append([]Card(nil), cards[:position]...)
+	copy(cards, cards[position:])
+	copy(cards[len(cards)-position:], top)
+}
+
+func passesOrDefault(passes int, defaultPasses int) int {
+	if passes == 0 {
+		return defaultPasses
+	}
+	return passes
+}
@@ -0,0 +1,98 @@
+package deck
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShuffler(t *testing.T) {
+	methods := map[string]Shuffler{
+		"":             FisherYates{},
+		"fisher-yates": FisherYates{},
+		"riffle":       Riffle{Passes: 3},
+		"Overhand":     Overhand{Passes: 3},
+		"cut":          Cut{Passes: 3},
+		"pile":         Pile{Passes: 3},
+	}
+	for method, expected := range methods {
+		shuffler, error := NewShuffler(method, 3)
+		assert.Nil(t, error)
+		assert.Equal(t, expected, shuffler)
+	}
+
+	_, error := NewShuffler("bogus", 0)
+	assert.NotNil(t, error)
+	_, error = NewShuffler("riffle", -1)
+	assert.NotNil(t, error)
+	_, error = NewShuffler("pile", MaxShufflePasses+1)
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+	_, error = NewShuffler("riffle", MaxShufflePasses)
+	assert.Nil(t, error)
+}
+
+func TestShufflersKeepAllCards(t *testing.T) {
+	shufflers := []Shuffler{FisherYates{}, Riffle{}, Overhand{}, Cut{}, Pile{}, Pile{Piles: 7, Passes: 3}}
+	r := rand.New(rand.NewSource(1))
+
+	for _, shuffler := range shufflers {
+		for _, size := range []int{0, 1, 2, 52} {
+			cards := newSequentialDeck().Cards[:size]
+			shuffled := append([]Card(nil), cards...)
+			shuffler.Shuffle(shuffled, r)
+			assert.ElementsMatch(t, cards, shuffled, "%T with %d cards", shuffler, size)
+		}
+	}
+}
+
+func TestShufflersReproducibleWithSameSeed(t *testing.T) {
+	shufflers := []Shuffler{FisherYates{}, Riffle{}, Overhand{}, Cut{}, Pile{}}
+	for _, shuffler := range shufflers {
+		first := newSequentialDeck().Cards
+		second := newSequentialDeck().Cards
+		shuffler.Shuffle(first, rand.New(rand.NewSource(42)))
+		shuffler.Shuffle(second, rand.New(rand.NewSource(42)))
+		assert.Equal(t, first, second, "%T", shuffler)
+		assert.NotEqual(t, newSequentialDeck().Cards, first, "%T", shuffler)
+	}
+}
+
+func TestFisherYatesIsUniform(t *testing.T) {
+	// every permutation of 3 cards must be (nearly) equally likely,
+	// the naive swap-with-any-index shuffle fails this test
+	const runs = 60000
+	r := rand.New(rand.NewSource(7))
+	cards := newSequentialDeck().Cards[:3]
+	counts := map[string]int{}
+	for i := 0; i < runs; i++ {
+		shuffled := append([]Card(nil), cards...)
+		FisherYates{}.Shuffle(shuffled, r)
+		counts[shuffled[0].Code()+shuffled[1].Code()+shuffled[2].Code()]++
+	}
+
+	assert.Equal(t, 6, len(counts))
+	expected := float64(runs) / 6
+	chiSquare := 0.0
+	for _, count := range counts {
+		diff := float64(count) - expected
+		chiSquare += diff * diff / expected
+	}
+	// critical value for 5 degrees of freedom at p = 0.001
+	assert.Less(t, chiSquare, 20.52)
+}
+
+func TestCutMovesTopBelowBottom(t *testing.T) {
+	cards := newSequentialDeck().Cards[:5]
+	cut := append([]Card(nil), cards...)
+	cutCards(cut, 2)
+	assert.Equal(t, append(append([]Card(nil), cards[2:]...), cards[:2]...), cut)
+}
+
+func TestPileShuffleWithOnePileReverses(t *testing.T) {
+	cards := newSequentialDeck().Cards[:4]
+	shuffled := append([]Card(nil), cards...)
+	Pile{Piles: 1}.Shuffle(shuffled, rand.New(rand.NewSource(1)))
+	assert.Equal(t, []Card{cards[3], cards[2], cards[1], cards[0]}, shuffled)
+}
@@ -0,0 +1,162 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maximum number of named snapshots of a deck
+const MaxSnapshots = 10
+
+// type to keep the state of a deck at some point, which can be restored later
+type Snapshot struct {
+	Name      string
+	CreatedAt time.Time
+	Cards     []Card
+	Shuffled  bool
+	Closed    bool              `json:",omitempty"`
+	Drawn     []Card            `json:",omitempty"`
+	Piles     map[string][]Card `json:",omitempty"`
+	// index of the event in the history of the deck which saved the snapshot
+	Event int
+}
+
+/*
+Creates a new deck with the same cards in the same order, drawn cards and
+piles as an existing deck. The clone keeps the history of the deck, so that
+it can be replayed, but not its snapshots nor its shuffle proof, so that the
+server seed cannot be revealed by closing a clone. Later operations on either
+deck do not change the other
+inputs:
+	deckId :  a UUID in string format
+returns:
+	the new deck with its own UUID
+	error if deck not found or store fails
+*/
+func (s *Service) CloneDeck(deckId string) (Deck, error) {
+	source, e := s.OpenDeck(deckId)
+	if e != nil {
+		return Deck{}, e
+	}
+	d := source.clone()
+	d.DeckId = uuid.New()
+	d.CreatedAt = time.Now().UTC()
+	d.Version = 0
+	d.Snapshots = nil
+	d.Proof = nil
+	d.record(Event{Type: EventClone, Source: source.DeckId.String()})
+	e = s.store.Put(d)
+	return d, e
+}
+
+/*
+Saves the current state of a deck as a named snapshot, a snapshot with the
+same name is replaced
+inputs:
+	deckId :  a UUID in string format
+	name   :  name of the snapshot, letters, digits, - and _ as for piles
+returns:
+	the updated deck
+	error if deck not found, name is invalid or the deck has MaxSnapshots
+	other snapshots
+*/
+func (s *Service) SaveSnapshot(deckId string, name string) (Deck, error) {
+	if e := validatePileName(name); e != nil {
+		return Deck{}, e
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		if _, exists := deck.Snapshots[name]; !exists && len(deck.Snapshots) >= MaxSnapshots {
+			message := fmt.Sprintf("deck has %d snapshots already, no more can be saved", MaxSnapshots)
+			return newError(ErrTooManySnapshots, message)
+		}
+		deck.record(Event{Type: EventSnapshot, Snapshot: name})
+		if deck.Snapshots == nil {
+			deck.Snapshots = map[string]Snapshot{}
+		}
+		deck.Snapshots[name] = deck.snapshot(name)
+		return nil
+	})
+}
+
+/*
+Returns the snapshots of a deck ordered by name
+inputs:
+	deckId :  a UUID in string format
+returns:
+	the snapshots
+	error if deck not found
+*/
+func (s *Service) Snapshots(deckId string) ([]Snapshot, error) {
+	deck, e := s.OpenDeck(deckId)
+	if e != nil {
+		return nil, e
+	}
+	snapshots := []Snapshot{}
+	for _, snapshot := range deck.Snapshots {
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+/*
+Restores the cards, drawn cards and piles of a deck from a snapshot. The
+history is kept and the restore is recorded with the index of the event
+which saved the snapshot, so that replay can rebuild the restored state. The
+snapshot is kept, so that it can be restored again. A closed deck cannot be
+restored, as its server seed may have been revealed
+inputs:
+	deckId :  a UUID in string format
+	name   :  name of the snapshot
+returns:
+	the restored deck
+	error if deck or snapshot not found or the deck is closed
+*/
+func (s *Service) RestoreSnapshot(deckId string, name string) (Deck, error) {
+	return s.mutate(deckId, func(deck *Deck) error {
+		snapshot, exists := deck.Snapshots[name]
+		if !exists {
+			message := fmt.Sprintf("snapshot %v not found in deck %v", name, deckId)
+			return newError(ErrSnapshotNotFound, message)
+		}
+		if deck.Closed {
+			return newError(ErrDeckClosed, "cannot restore a snapshot, deck is closed")
+		}
+		snapshot = snapshot.clone()
+		deck.Cards = snapshot.Cards
+		deck.Shuffled = snapshot.Shuffled
+		deck.Closed = snapshot.Closed
+		deck.Drawn = snapshot.Drawn
+		deck.Piles = snapshot.Piles
+		deck.record(Event{Type: EventRestore, Snapshot: name, Events: []int{snapshot.Event}})
+		return nil
+	})
+}
+
+// returns a snapshot of the current state of the deck
+func (d Deck) snapshot(name string) Snapshot {
+	d.Snapshots = nil
+	d = d.clone()
+	return Snapshot{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		Cards:     d.Cards,
+		Shuffled:  d.Shuffled,
+		Closed:    d.Closed,
+		Drawn:     d.Drawn,
+		Piles:     d.Piles,
+		Event:     len(d.History) - 1,
+	}
+}
+
+// returns a copy of the snapshot which does not share its cards and piles with the receiver
+func (s Snapshot) clone() Snapshot {
+	d := Deck{Cards: s.Cards, Drawn: s.Drawn, Piles: s.Piles}.clone()
+	s.Cards, s.Drawn, s.Piles = d.Cards, d.Drawn, d.Piles
+	return s
+}
@@ -0,0 +1,128 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloneDeck(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "")
+	deck_id := d.DeckId.String()
+	service.Deal(deck_id, 2, 3, "")
+	service.DrawCards(deck_id, 1)
+	source, _ := service.OpenDeck(deck_id)
+
+	clone, error := service.CloneDeck(deck_id)
+	assert.Nil(t, error)
+	assert.NotEqual(t, source.DeckId, clone.DeckId)
+	assert.Equal(t, source.Cards, clone.Cards)
+	assert.Equal(t, source.Drawn, clone.Drawn)
+	assert.Equal(t, source.Piles, clone.Piles)
+	last := clone.History[len(clone.History)-1]
+	assert.Equal(t, EventClone, last.Type)
+	assert.Equal(t, deck_id, last.Source)
+
+	// the clone branches off without changing the source
+	service.DrawCards(clone.DeckId.String(), 5)
+	service.ReturnPile(clone.DeckId.String(), "player1", FromTop)
+	opened, _ := service.OpenDeck(deck_id)
+	assert.Equal(t, source.Cards, opened.Cards)
+	assert.Equal(t, source.Piles, opened.Piles)
+
+	clone, _ = service.OpenDeck(clone.DeckId.String())
+	replayed, error := Replay(clone, len(clone.History)-1)
+	assert.Nil(t, error)
+	assert.Equal(t, clone.Cards, replayed.Cards)
+
+	_, error = service.CloneDeck("e4b6a1a4-2a47-4fba-9c55-9b1a3c5e2d10")
+	assert.NotNil(t, error)
+}
+
+func TestSnapshots(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 2)
+	saved, error := service.SaveSnapshot(deck_id, "flop")
+	assert.Nil(t, error)
+
+	service.Burn(deck_id, 1)
+	service.DrawCards(deck_id, 3)
+	service.SaveSnapshot(deck_id, "turn")
+
+	snapshots, error := service.Snapshots(deck_id)
+	assert.Nil(t, error)
+	assert.Equal(t, 2, len(snapshots))
+	assert.Equal(t, "flop", snapshots[0].Name)
+	assert.Equal(t, 50, len(snapshots[0].Cards))
+	assert.Equal(t, "turn", snapshots[1].Name)
+
+	deck, error := service.RestoreSnapshot(deck_id, "flop")
+	assert.Nil(t, error)
+	assert.Equal(t, saved.Cards, deck.Cards)
+	assert.Equal(t, saved.Drawn, deck.Drawn)
+	assert.Equal(t, 0, len(deck.Piles))
+	assert.Equal(t, 2, len(deck.Snapshots))
+	// the history is kept and the restore refers to the saved snapshot
+	restore := deck.History[len(deck.History)-1]
+	assert.Equal(t, saved.History, deck.History[:len(saved.History)])
+	assert.Equal(t, len(saved.History)+4, len(deck.History))
+	assert.Equal(t, EventRestore, restore.Type)
+	assert.Equal(t, []int{len(saved.History) - 1}, restore.Events)
+
+	// a restore cannot be undone, the snapshot can be restored again
+	_, error = service.Undo(deck_id, 1, deck.Version)
+	assert.NotNil(t, error)
+	service.DrawCards(deck_id, 10)
+	deck, _ = service.RestoreSnapshot(deck_id, "flop")
+	assert.Equal(t, saved.Cards, deck.Cards)
+
+	replayed, error := Replay(deck, len(deck.History)-1)
+	assert.Nil(t, error)
+	assert.Equal(t, deck.Cards, replayed.Cards)
+	assert.Equal(t, deck.Drawn, replayed.Drawn)
+	// the state before the restore is replayed as well
+	replayed, error = Replay(deck, len(deck.History)-2)
+	assert.Nil(t, error)
+	assert.Equal(t, 12, len(replayed.Drawn))
+
+	// a restore has to refer to a snapshot event
+	deck.History[len(deck.History)-1].Events = []int{0}
+	_, error = Replay(deck, len(deck.History)-1)
+	assert.True(t, errors.Is(error, ErrInvalidHistory))
+}
+
+func TestRestoreClosedDeck(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "")
+	deck_id := d.DeckId.String()
+	service.SaveSnapshot(deck_id, "start")
+	service.CloseDeck(deck_id)
+	_, error := service.RevealSeed(deck_id)
+	assert.Nil(t, error)
+
+	// the revealed seed tells the order of the cards, the deck is not reopened
+	_, error = service.RestoreSnapshot(deck_id, "start")
+	assert.True(t, errors.Is(error, ErrDeckClosed))
+	deck, _ := service.OpenDeck(deck_id)
+	assert.True(t, deck.Closed)
+}
+
+func TestSnapshotsFailure(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "AS,KD")
+	deck_id := d.DeckId.String()
+
+	_, error := service.SaveSnapshot(deck_id, "not valid")
+	assert.NotNil(t, error)
+	_, error = service.RestoreSnapshot(deck_id, "missing")
+	assert.NotNil(t, error)
+
+	for i := 0; i < MaxSnapshots; i++ {
+		_, error = service.SaveSnapshot(deck_id, PlayerPile(i))
+		assert.Nil(t, error)
+	}
+	_, error = service.SaveSnapshot(deck_id, "one-more")
+	assert.NotNil(t, error)
+	_, error = service.SaveSnapshot(deck_id, PlayerPile(0))
+	assert.Nil(t, error, "an existing snapshot can be replaced")
+}
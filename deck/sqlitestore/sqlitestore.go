@@ -0,0 +1,451 @@
+/*
+Package sqlitestore provides a deck.DeckStore which keeps decks, their cards
+and the history of drawn cards in a SQLite database.
+
+It uses a pure Go SQLite driver, so it builds without cgo. The database
+schema is versioned and pending migrations are applied when the store is opened.
+*/
+package sqlitestore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	_ "modernc.org/sqlite"
+)
+
+// fixed width UTC layout, so that stored timestamps sort lexicographically
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+/*
+Schema migrations, applied in order. Migration at index i brings the schema
+to version i+1. Existing migrations must never be changed, schema changes
+are made by appending a new migration.
+*/
+var migrations = []string{
+	`CREATE TABLE decks (
+		id         TEXT PRIMARY KEY,
+		shuffled   INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		version    INTEGER NOT NULL,
+		state      TEXT NOT NULL
+	);
+	CREATE INDEX decks_created_at ON decks (created_at, id);
+
+	CREATE TABLE deck_cards (
+		deck_id  TEXT NOT NULL REFERENCES decks (id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		code     TEXT NOT NULL,
+		value    TEXT NOT NULL,
+		suit     TEXT NOT NULL,
+		PRIMARY KEY (deck_id, position)
+	);
+
+	CREATE TABLE draws (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		deck_id  TEXT NOT NULL REFERENCES decks (id) ON DELETE CASCADE,
+		code     TEXT NOT NULL,
+		value    TEXT NOT NULL,
+		suit     TEXT NOT NULL,
+		drawn_at TEXT NOT NULL
+	);
+	CREATE INDEX draws_deck_id ON draws (deck_id, id);`,
+
+	// cards of custom card sets, attributes are stored as json
+	`ALTER TABLE deck_cards ADD COLUMN cardset TEXT NOT NULL DEFAULT '';
+	ALTER TABLE deck_cards ADD COLUMN attributes TEXT;
+	ALTER TABLE draws ADD COLUMN cardset TEXT NOT NULL DEFAULT '';
+	ALTER TABLE draws ADD COLUMN attributes TEXT;`,
+}
+
+// DeckStore backed by a SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+var _ deck.DeckStore = (*Store)(nil)
+
+/*
+Opens the SQLite database at given path, creating it if needed, and applies
+pending schema migrations.
+inputs:
+	path :  path of the database file, ":memory:" for a private in-memory database
+returns:
+	a store ready for use
+	error if database cannot be opened or migrated
+*/
+func Open(path string) (*Store, error) {
+	dsn := fmt.Sprintf("file:%v?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database %v: %w", path, err)
+	}
+	// sqlite allows a single writer, a single connection also keeps ":memory:" databases shared
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// returns the version of the database schema
+func (s *Store) SchemaVersion() (int, error) {
+	var version int
+	err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+/*
+Applies all migrations newer than the current schema version,
+each in its own transaction together with its version record
+*/
+func (s *Store) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("cannot create migrations table: %w", err)
+	}
+
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("cannot read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		err := s.inTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(migrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+				version, formatTime(time.Now()))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("cannot apply schema migration %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) Get(id uuid.UUID) (deck.Deck, error) {
+	var d deck.Deck
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		d, err = getDeck(tx, id)
+		return err
+	})
+	return d, err
+}
+
+func (s *Store) Put(d deck.Deck) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM deck_cards WHERE deck_id = ?`, d.DeckId.String()); err != nil {
+			return err
+		}
+		state, err := encodeState(d)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO decks (id, shuffled, created_at, version, state) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET shuffled = excluded.shuffled, created_at = excluded.created_at,
+				version = excluded.version, state = excluded.state`,
+			d.DeckId.String(), d.Shuffled, formatTime(d.CreatedAt), d.Version, state)
+		if err != nil {
+			return err
+		}
+		return insertCards(tx, d)
+	})
+}
+
+func (s *Store) Delete(id uuid.UUID) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM decks WHERE id = ?`, id.String())
+		if err != nil {
+			return err
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return deck.ErrDeckNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Store) List() ([]deck.Deck, error) {
+	return s.listDecks(`SELECT id FROM decks ORDER BY created_at, id`)
+}
+
+// reads a single page of decks using the decks_created_at index
+func (s *Store) ListPage(limit int, after *deck.ListPosition) ([]deck.Deck, error) {
+	if after == nil {
+		return s.listDecks(`SELECT id FROM decks ORDER BY created_at, id LIMIT ?`, limit)
+	}
+	createdAt := formatTime(after.CreatedAt)
+	return s.listDecks(`SELECT id FROM decks WHERE created_at > ? OR (created_at = ? AND id > ?)
+		ORDER BY created_at, id LIMIT ?`, createdAt, createdAt, after.DeckId.String(), limit)
+}
+
+// returns the decks whose ids are selected by the query, in the order of the query
+func (s *Store) listDecks(query string, args ...interface{}) ([]deck.Deck, error) {
+	decks := []deck.Deck{}
+	err := s.inTx(func(tx *sql.Tx) error {
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		ids := []uuid.UUID{}
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			d, err := getDeck(tx, id)
+			if err != nil {
+				return err
+			}
+			decks = append(decks, d)
+		}
+		return nil
+	})
+	return decks, err
+}
+
+/*
+Compare-and-swap update of the deck. Runs in a single transaction which bumps
+the version, replaces the cards and records every card which left the deck
+in the draw history
+*/
+func (s *Store) Update(d deck.Deck) (deck.Deck, error) {
+	var updated deck.Deck
+	err := s.inTx(func(tx *sql.Tx) error {
+		stored, err := getDeck(tx, d.DeckId)
+		if err != nil {
+			return err
+		}
+		if stored.Version != d.Version {
+			return deck.ErrVersionConflict
+		}
+
+		updated = d
+		updated.Version++
+		state, err := encodeState(updated)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE decks SET shuffled = ?, version = ?, state = ? WHERE id = ?`,
+			updated.Shuffled, updated.Version, state, d.DeckId.String())
+		if err != nil {
+			return err
+		}
+
+		if err := recordDraws(tx, d.DeckId, stored.Cards, updated.Cards); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM deck_cards WHERE deck_id = ?`, d.DeckId.String()); err != nil {
+			return err
+		}
+		return insertCards(tx, updated)
+	})
+	if err != nil {
+		return deck.Deck{}, err
+	}
+	return updated, nil
+}
+
+/*
+Returns the cards drawn from the deck with given id, oldest draw first
+*/
+func (s *Store) DrawHistory(id uuid.UUID) ([]deck.Card, error) {
+	rows, err := s.db.Query(`SELECT code, value, suit, cardset, attributes FROM draws WHERE deck_id = ? ORDER BY id`, id.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanCards(rows)
+}
+
+// runs fn in a transaction which is committed if fn succeeds and rolled back otherwise
+func (s *Store) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// reads the deck with given id and its cards in order
+func getDeck(tx *sql.Tx, id uuid.UUID) (deck.Deck, error) {
+	var d deck.Deck
+	var createdAt, state string
+	err := tx.QueryRow(`SELECT shuffled, created_at, version, state FROM decks WHERE id = ?`, id.String()).
+		Scan(&d.Shuffled, &createdAt, &d.Version, &state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, deck.ErrDeckNotFound
+	}
+	if err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal([]byte(state), &d); err != nil {
+		return d, fmt.Errorf("cannot decode deck %v: %w", id, err)
+	}
+	d.DeckId = id
+	d.CreatedAt, err = time.Parse(timeLayout, createdAt)
+	if err != nil {
+		return d, err
+	}
+
+	rows, err := tx.Query(`SELECT code, value, suit, cardset, attributes FROM deck_cards WHERE deck_id = ? ORDER BY position`, id.String())
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+	d.Cards, err = scanCards(rows)
+	return d, err
+}
+
+/*
+Reads cards from rows of code, value, suit, card set and attributes. Standard
+cards are identified by their code, custom cards are read with their value
+as name and their attributes
+*/
+func scanCards(rows *sql.Rows) ([]deck.Card, error) {
+	cards := []deck.Card{}
+	for rows.Next() {
+		var code, value, suit, cardSet string
+		var attributes sql.NullString
+		if err := rows.Scan(&code, &value, &suit, &cardSet, &attributes); err != nil {
+			return nil, err
+		}
+		if len(cardSet) > 0 {
+			definition := deck.CardDefinition{Code: code, Name: value}
+			if attributes.Valid {
+				if err := json.Unmarshal([]byte(attributes.String), &definition.Attributes); err != nil {
+					return nil, fmt.Errorf("cannot decode attributes of card %v: %w", code, err)
+				}
+			}
+			cards = append(cards, deck.NewCustomCard(cardSet, definition))
+			continue
+		}
+		c, err := deck.ParseCard(code)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// inserts all cards of the deck with their positions
+func insertCards(tx *sql.Tx, d deck.Deck) error {
+	stmt, err := tx.Prepare(`INSERT INTO deck_cards (deck_id, position, code, value, suit, cardset, attributes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for position, c := range d.Cards {
+		attributes, err := encodeAttributes(c)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(d.DeckId.String(), position, c.Code(), c.Name(), c.Suit.Name(), c.CardSet, attributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the attributes of a custom card as json, nil if the card has none
+func encodeAttributes(c deck.Card) (interface{}, error) {
+	if c.Custom == nil || len(c.Custom.Attributes) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(c.Custom.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode attributes of card %v: %w", c.Code(), err)
+	}
+	return string(data), nil
+}
+
+/*
+Adds every card which is in before but no longer in after to the draw history.
+Cards are counted by cardKey, so identical cards are recorded once per copy
+which left the deck, in the order they were in before
+*/
+func recordDraws(tx *sql.Tx, id uuid.UUID, before []deck.Card, after []deck.Card) error {
+	left := map[string]int{}
+	for _, c := range before {
+		left[cardKey(c)]++
+	}
+	for _, c := range after {
+		left[cardKey(c)]--
+	}
+	drawnAt := formatTime(time.Now())
+	for _, c := range before {
+		if left[cardKey(c)] <= 0 {
+			continue
+		}
+		left[cardKey(c)]--
+		attributes, err := encodeAttributes(c)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO draws (deck_id, code, value, suit, cardset, attributes, drawn_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id.String(), c.Code(), c.Name(), c.Suit.Name(), c.CardSet, attributes, drawnAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identifies identical cards
+func cardKey(c deck.Card) string {
+	return c.CardSet + ":" + c.Code()
+}
+
+/*
+Encodes the deck fields which do not have their own columns. Cards are
+stored in their own table, so they are left out
+*/
+func encodeState(d deck.Deck) (string, error) {
+	d.Cards = nil
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode deck %v: %w", d.DeckId, err)
+	}
+	return string(data), nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,224 @@
+package sqlitestore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ketanbodas/manage-card-deck/deck"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrationsApplied(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decks.db")
+	store, err := Open(path)
+	assert.Nil(t, err)
+	version, err := store.SchemaVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, len(migrations), version)
+	store.Close()
+
+	// reopening applies no migration twice
+	store, err = Open(path)
+	assert.Nil(t, err)
+	version, _ = store.SchemaVersion()
+	assert.Equal(t, len(migrations), version)
+	store.Close()
+}
+
+func TestServiceOnSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decks.db")
+	store, _ := Open(path)
+	s := deck.NewService(store)
+
+	d, err := s.CreateNewDeck(false, "AS,KD,AC,2C,KH,10H")
+	assert.Nil(t, err)
+	deckId := d.DeckId.String()
+
+	hand, err := s.DrawCards(deckId, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "AS", hand[0].Code())
+	assert.Equal(t, "KD", hand[1].Code())
+	store.Close()
+
+	// deck and draw history survive reopening the database
+	store, _ = Open(path)
+	defer store.Close()
+	opened, err := deck.NewService(store).OpenDeck(deckId)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(opened.Cards))
+	assert.Equal(t, "AC", opened.Cards[0].Code())
+	assert.Equal(t, "10H", opened.Cards[3].Code())
+	assert.Equal(t, uint64(1), opened.Version)
+	assert.True(t, d.CreatedAt.Equal(opened.CreatedAt))
+
+	drawn, err := store.DrawHistory(d.DeckId)
+	assert.Nil(t, err)
+	assert.Equal(t, hand, drawn)
+}
+
+func TestShoeOnSQLiteStore(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), "decks.db"))
+	defer store.Close()
+	s := deck.NewService(store)
+
+	d, err := s.CreateDeck(deck.DeckOptions{Codes: "AS,KD", DecksCount: 3, Penetration: 0.5})
+	assert.Nil(t, err)
+	hand, err := s.DrawCards(d.DeckId.String(), 3)
+	assert.Nil(t, err)
+
+	// identical cards are kept and recorded as drawn once per copy
+	opened, _ := s.OpenDeck(d.DeckId.String())
+	assert.Equal(t, 3, len(opened.Cards))
+	assert.Equal(t, 3, opened.DecksCount)
+	assert.True(t, opened.NeedsReshuffle())
+	drawn, _ := store.DrawHistory(d.DeckId)
+	assert.Equal(t, hand, drawn)
+}
+
+func TestCustomCardsOnSQLiteStore(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), "decks.db"))
+	defer store.Close()
+	s := deck.NewService(store)
+
+	set, _ := deck.RegisterCardSet(deck.CardSet{Cards: []deck.CardDefinition{
+		{Code: "T0", Name: "The Fool", Attributes: map[string]interface{}{"arcana": "major"}},
+		{Code: "T1", Name: "The Magician"},
+	}})
+	d, err := s.CreateDeck(deck.DeckOptions{CardSet: set.Id})
+	assert.Nil(t, err)
+	hand, err := s.DrawCards(d.DeckId.String(), 1)
+	assert.Nil(t, err)
+
+	opened, _ := s.OpenDeck(d.DeckId.String())
+	assert.Equal(t, set.Id, opened.CardSet)
+	assert.Equal(t, 1, len(opened.Cards))
+	assert.Equal(t, "The Magician", opened.Cards[0].Name())
+	drawn, _ := store.DrawHistory(d.DeckId)
+	assert.Equal(t, hand, drawn)
+	assert.Equal(t, "major", drawn[0].Custom.Attributes["arcana"])
+}
+
+func TestReturnedCardsAreNotRecordedAsDrawn(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), "decks.db"))
+	defer store.Close()
+	s := deck.NewService(store)
+
+	d, _ := s.CreateNewDeck(false, "AS,KD,AC,2C")
+	deckId := d.DeckId.String()
+	s.DrawCards(deckId, 2)
+	_, err := s.ReturnCards(deckId, "KD", deck.FromRandom)
+	assert.Nil(t, err)
+	_, err = s.ReshuffleRemaining(deckId, "", 0)
+	assert.Nil(t, err)
+
+	opened, _ := s.OpenDeck(deckId)
+	assert.Equal(t, 3, len(opened.Cards))
+	drawn, _ := store.DrawHistory(d.DeckId)
+	assert.Equal(t, []string{"AS", "KD"}, []string{drawn[0].Code(), drawn[1].Code()})
+}
+
+func TestUpdateCompareAndSwap(t *testing.T) {
+	store, _ := Open(":memory:")
+	defer store.Close()
+	d := deck.Deck{DeckId: uuid.New(), Cards: []deck.Card{{Rank: deck.Ace, Suit: deck.Spades}}}
+	assert.Nil(t, store.Put(d))
+
+	next := d
+	next.Cards = []deck.Card{}
+	updated, err := store.Update(next)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), updated.Version)
+
+	// stale version is rejected and nothing changes
+	_, err = store.Update(next)
+	assert.ErrorIs(t, err, deck.ErrVersionConflict)
+	drawn, _ := store.DrawHistory(d.DeckId)
+	assert.Equal(t, 1, len(drawn))
+
+	_, err = store.Update(deck.Deck{DeckId: uuid.New()})
+	assert.ErrorIs(t, err, deck.ErrDeckNotFound)
+}
+
+func TestListByCreationTimeAndDelete(t *testing.T) {
+	store, _ := Open(":memory:")
+	defer store.Close()
+	now := time.Now()
+	second := deck.Deck{DeckId: uuid.New(), CreatedAt: now}
+	first := deck.Deck{DeckId: uuid.New(), CreatedAt: now.Add(-time.Hour)}
+	store.Put(second)
+	store.Put(first)
+
+	decks, err := store.List()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(decks))
+	assert.Equal(t, first.DeckId, decks[0].DeckId)
+	assert.Equal(t, second.DeckId, decks[1].DeckId)
+
+	assert.Nil(t, store.Delete(first.DeckId))
+	assert.ErrorIs(t, store.Delete(first.DeckId), deck.ErrDeckNotFound)
+	_, err = store.Get(first.DeckId)
+	assert.ErrorIs(t, err, deck.ErrDeckNotFound)
+}
+
+func TestListPage(t *testing.T) {
+	store, _ := Open(":memory:")
+	defer store.Close()
+	now := time.Now()
+	first := deck.Deck{DeckId: uuid.New(), CreatedAt: now}
+	second := deck.Deck{DeckId: uuid.New(), CreatedAt: now.Add(time.Hour)}
+	third := deck.Deck{DeckId: uuid.New(), CreatedAt: now.Add(time.Hour)}
+	if third.DeckId.String() < second.DeckId.String() {
+		second, third = third, second
+	}
+	store.Put(third)
+	store.Put(first)
+	store.Put(second)
+
+	decks, err := store.ListPage(2, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []uuid.UUID{first.DeckId, second.DeckId}, []uuid.UUID{decks[0].DeckId, decks[1].DeckId})
+	decks, err = store.ListPage(2, &deck.ListPosition{CreatedAt: second.CreatedAt, DeckId: second.DeckId})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(decks))
+	assert.Equal(t, third.DeckId, decks[0].DeckId)
+	decks, _ = store.ListPage(2, &deck.ListPosition{CreatedAt: first.CreatedAt, DeckId: first.DeckId})
+	assert.Equal(t, 2, len(decks))
+
+	// the service pages through the store
+	service := deck.NewService(store)
+	page, cursor, err := service.ListDecksPage(1, "")
+	assert.Nil(t, err)
+	assert.Equal(t, first.DeckId, page[0].DeckId)
+	page, _, _ = service.ListDecksPage(5, cursor)
+	assert.Equal(t, 2, len(page))
+}
+
+func TestConcurrentDrawsOnSQLite(t *testing.T) {
+	store, _ := Open(filepath.Join(t.TempDir(), "decks.db"))
+	defer store.Close()
+	s := deck.NewService(store)
+	d, _ := s.CreateNewDeck(true, "")
+
+	results := make(chan []deck.Card, 60)
+	for i := 0; i < 60; i++ {
+		go func() {
+			hand, err := s.DrawCards(d.DeckId.String(), 1)
+			if err != nil {
+				hand = nil
+			}
+			results <- hand
+		}()
+	}
+	dealt := map[string]int{}
+	for i := 0; i < 60; i++ {
+		for _, c := range <-results {
+			dealt[c.Code()]++
+		}
+	}
+	assert.Equal(t, 52, len(dealt))
+	for _, count := range dealt {
+		assert.Equal(t, 1, count)
+	}
+}
@@ -0,0 +1,157 @@
+package deck
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errors returned by deck stores
+var (
+	ErrDeckNotFound    = errors.New("deck not found")
+	ErrVersionConflict = errors.New("deck was modified by another operation")
+)
+
+/*
+DeckStore is the storage layer used by Service to keep generated decks.
+Implementations must be safe for concurrent use and must never share card
+slices with the caller, i.e. decks are stored and returned as copies.
+*/
+type DeckStore interface {
+	// returns the deck with given id or ErrDeckNotFound
+	Get(id uuid.UUID) (Deck, error)
+
+	// stores the deck, replacing any existing deck with the same id
+	Put(d Deck) error
+
+	// removes the deck with given id or returns ErrDeckNotFound
+	Delete(id uuid.UUID) error
+
+	// returns all stored decks ordered by creation time
+	List() ([]Deck, error)
+
+	// returns up to limit decks ordered by creation time which are listed after the
+	// given position, from the first deck if after is nil, see ListPosition
+	ListPage(limit int, after *ListPosition) ([]Deck, error)
+
+	// compare-and-swap: replaces the stored deck only if its version is still d.Version
+	// and returns the stored deck with incremented version, ErrVersionConflict otherwise
+	Update(d Deck) (Deck, error)
+}
+
+// position of a deck in the list of decks, which is ordered by creation time and deck id
+type ListPosition struct {
+	CreatedAt time.Time
+	DeckId    uuid.UUID
+}
+
+// returns true if the deck is listed after the position, see sortByCreation
+func (p ListPosition) Before(d Deck) bool {
+	if d.CreatedAt.Equal(p.CreatedAt) {
+		return d.DeckId.String() > p.DeckId.String()
+	}
+	return d.CreatedAt.After(p.CreatedAt)
+}
+
+// in-memory implementation of DeckStore
+type memoryStore struct {
+	mu    sync.RWMutex
+	decks map[uuid.UUID]Deck
+}
+
+/*
+Returns a DeckStore which keeps decks in memory.
+Decks are lost once the process stops.
+*/
+func NewMemoryStore() DeckStore {
+	return &memoryStore{decks: map[uuid.UUID]Deck{}}
+}
+
+func (s *memoryStore) Get(id uuid.UUID) (Deck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, exists := s.decks[id]
+	if !exists {
+		return Deck{}, ErrDeckNotFound
+	}
+	return d.clone(), nil
+}
+
+func (s *memoryStore) Put(d Deck) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decks[d.DeckId] = d.clone()
+	return nil
+}
+
+func (s *memoryStore) Delete(id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.decks[id]; !exists {
+		return ErrDeckNotFound
+	}
+	delete(s.decks, id)
+	return nil
+}
+
+func (s *memoryStore) List() ([]Deck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	decks := make([]Deck, 0, len(s.decks))
+	for _, d := range s.decks {
+		decks = append(decks, d.clone())
+	}
+	sortByCreation(decks)
+	return decks, nil
+}
+
+func (s *memoryStore) ListPage(limit int, after *ListPosition) ([]Deck, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	decks := []Deck{}
+	for _, d := range s.decks {
+		if after == nil || after.Before(d) {
+			decks = append(decks, d)
+		}
+	}
+	sortByCreation(decks)
+	if len(decks) > limit {
+		decks = decks[:limit]
+	}
+	for i := range decks {
+		decks[i] = decks[i].clone()
+	}
+	return decks, nil
+}
+
+func (s *memoryStore) Update(d Deck) (Deck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, exists := s.decks[d.DeckId]
+	if !exists {
+		return Deck{}, ErrDeckNotFound
+	}
+	if stored.Version != d.Version {
+		return Deck{}, ErrVersionConflict
+	}
+	d = d.clone()
+	d.Version++
+	s.decks[d.DeckId] = d
+	return d.clone(), nil
+}
+
+/*
+Sorts decks by creation time, oldest first. Ties are broken by deck id so
+that listing is deterministic
+*/
+func sortByCreation(decks []Deck) {
+	sort.Slice(decks, func(i, j int) bool {
+		if decks[i].CreatedAt.Equal(decks[j].CreatedAt) {
+			return decks[i].DeckId.String() < decks[j].DeckId.String()
+		}
+		return decks[i].CreatedAt.Before(decks[j].CreatedAt)
+	})
+}
@@ -0,0 +1,113 @@
+package deck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStorePutGet(t *testing.T) {
+	store := NewMemoryStore()
+	d := newSequentialDeck()
+	d.DeckId = uuid.New()
+
+	assert.Nil(t, store.Put(d))
+
+	stored, error := store.Get(d.DeckId)
+	assert.Nil(t, error)
+	assert.Equal(t, d.DeckId, stored.DeckId)
+	assert.Equal(t, 52, len(stored.Cards))
+
+	// modifying returned deck should not modify stored deck
+	stored.Cards[0] = stored.Cards[1]
+	stored, _ = store.Get(d.DeckId)
+	assert.Equal(t, "AS", stored.Cards[0].Code())
+}
+
+func TestMemoryStoreGetUnknown(t *testing.T) {
+	store := NewMemoryStore()
+	_, error := store.Get(uuid.New())
+	assert.ErrorIs(t, error, ErrDeckNotFound)
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	store := NewMemoryStore()
+	d := Deck{DeckId: uuid.New()}
+	store.Put(d)
+
+	assert.Nil(t, store.Delete(d.DeckId))
+	_, error := store.Get(d.DeckId)
+	assert.ErrorIs(t, error, ErrDeckNotFound)
+	assert.ErrorIs(t, store.Delete(d.DeckId), ErrDeckNotFound)
+}
+
+func TestMemoryStoreListByCreationTime(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	second := Deck{DeckId: uuid.New(), CreatedAt: now}
+	first := Deck{DeckId: uuid.New(), CreatedAt: now.Add(-time.Minute)}
+	third := Deck{DeckId: uuid.New(), CreatedAt: now.Add(time.Minute)}
+	store.Put(second)
+	store.Put(third)
+	store.Put(first)
+
+	decks, error := store.List()
+	assert.Nil(t, error)
+	assert.Equal(t, 3, len(decks))
+	assert.Equal(t, first.DeckId, decks[0].DeckId)
+	assert.Equal(t, second.DeckId, decks[1].DeckId)
+	assert.Equal(t, third.DeckId, decks[2].DeckId)
+}
+
+func TestMemoryStoreListPage(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	first := Deck{DeckId: uuid.New(), CreatedAt: now}
+	second := Deck{DeckId: uuid.New(), CreatedAt: now.Add(time.Minute)}
+	third := Deck{DeckId: uuid.New(), CreatedAt: now.Add(time.Minute)}
+	if third.DeckId.String() < second.DeckId.String() {
+		second, third = third, second
+	}
+	store.Put(third)
+	store.Put(first)
+	store.Put(second)
+
+	decks, error := store.ListPage(2, nil)
+	assert.Nil(t, error)
+	assert.Equal(t, []uuid.UUID{first.DeckId, second.DeckId}, []uuid.UUID{decks[0].DeckId, decks[1].DeckId})
+	// decks created at the same time are ordered by id
+	decks, _ = store.ListPage(2, &ListPosition{CreatedAt: second.CreatedAt, DeckId: second.DeckId})
+	assert.Equal(t, 1, len(decks))
+	assert.Equal(t, third.DeckId, decks[0].DeckId)
+	decks, _ = store.ListPage(2, &ListPosition{CreatedAt: third.CreatedAt, DeckId: third.DeckId})
+	assert.Empty(t, decks)
+}
+
+func TestMemoryStoreUpdateCompareAndSwap(t *testing.T) {
+	store := NewMemoryStore()
+	d := newSequentialDeck()
+	d.DeckId = uuid.New()
+	store.Put(d)
+
+	// first update with current version succeeds
+	first, _ := store.Get(d.DeckId)
+	first.Cards = first.Cards[1:]
+	updated, error := store.Update(first)
+	assert.Nil(t, error)
+	assert.Equal(t, d.Version+1, updated.Version)
+
+	// update based on stale version fails and leaves deck unchanged
+	stale := d
+	stale.Cards = stale.Cards[2:]
+	_, error = store.Update(stale)
+	assert.ErrorIs(t, error, ErrVersionConflict)
+
+	stored, _ := store.Get(d.DeckId)
+	assert.Equal(t, 51, len(stored.Cards))
+
+	// update of unknown deck fails
+	_, error = store.Update(Deck{DeckId: uuid.New()})
+	assert.ErrorIs(t, error, ErrDeckNotFound)
+}
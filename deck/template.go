@@ -0,0 +1,144 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// names of the supported deck templates
+const (
+	StandardDeck = "standard"
+	PiquetDeck   = "piquet"
+	EuchreDeck   = "euchre"
+	PinochleDeck = "pinochle"
+	SpanishDeck  = "spanish"
+	ItalianDeck  = "italian"
+	ShortDeck    = "short"
+)
+
+/*
+Type to describe the composition of a deck. A deck made from a template has
+every rank of the template in each of the four suits, Copies times, and
+optionally a number of jokers
+*/
+type DeckTemplate struct {
+	Name string
+	// ranks in each suit, ordered as in a new deck
+	Ranks []Rank
+	// number of copies of each card, e.g. 2 for pinochle
+	Copies int
+	// whether jokers can be added to the deck
+	AllowsJokers bool
+}
+
+// supported deck templates by name
+var deckTemplates = map[string]DeckTemplate{
+	StandardDeck: {
+		Name:         StandardDeck,
+		Ranks:        ranks,
+		Copies:       1,
+		AllowsJokers: true,
+	},
+	PiquetDeck: {
+		Name:   PiquetDeck,
+		Ranks:  []Rank{Ace, Seven, Eight, Nine, Ten, Jack, Queen, King},
+		Copies: 1,
+	},
+	EuchreDeck: {
+		Name:   EuchreDeck,
+		Ranks:  []Rank{Ace, Nine, Ten, Jack, Queen, King},
+		Copies: 1,
+	},
+	PinochleDeck: {
+		Name:   PinochleDeck,
+		Ranks:  []Rank{Ace, Nine, Ten, Jack, Queen, King},
+		Copies: 2,
+	},
+	// 40 card decks play with ace to seven and the three face cards
+	SpanishDeck: {
+		Name:   SpanishDeck,
+		Ranks:  []Rank{Ace, Two, Three, Four, Five, Six, Seven, Jack, Queen, King},
+		Copies: 1,
+	},
+	ItalianDeck: {
+		Name:   ItalianDeck,
+		Ranks:  []Rank{Ace, Two, Three, Four, Five, Six, Seven, Jack, Queen, King},
+		Copies: 1,
+	},
+	ShortDeck: {
+		Name:   ShortDeck,
+		Ranks:  []Rank{Ace, Six, Seven, Eight, Nine, Ten, Jack, Queen, King},
+		Copies: 1,
+	},
+}
+
+/*
+Returns the deck template with given name
+inputs:
+	name :  name of the template, case insensitive. standard if empty
+returns:
+	the template
+	error if there is no template with the name
+*/
+func Template(name string) (DeckTemplate, error) {
+	name = normalizeTemplateName(name)
+	template, exists := deckTemplates[name]
+	if !exists {
+		message := fmt.Sprintf("deck type '%v' is not supported, should be one of %v",
+			name, strings.Join(TemplateNames(), ", "))
+		return template, newError(ErrInvalidArgument, message)
+	}
+	return template, nil
+}
+
+// returns the name in lower case, standard if empty
+func normalizeTemplateName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return StandardDeck
+	}
+	return name
+}
+
+// returns names of all deck templates in alphabetical order
+func TemplateNames() []string {
+	names := []string{}
+	for name := range deckTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Returns all cards of the template in the order of a new deck, followed by
+given number of jokers
+*/
+func (t DeckTemplate) Cards(jokers int) []Card {
+	cards := []Card{}
+	for _, suit := range suits {
+		for _, rank := range t.Ranks {
+			for copy := 0; copy < t.Copies; copy++ {
+				cards = append(cards, Card{Rank: rank, Suit: suit})
+			}
+		}
+	}
+	for i := 0; i < jokers; i++ {
+		cards = append(cards, Card{Rank: Joker})
+	}
+	return cards
+}
+
+// returns true if the card can be part of a deck made from the template
+func (t DeckTemplate) Contains(card Card) bool {
+	if card.IsJoker() {
+		return t.AllowsJokers
+	}
+	for _, rank := range t.Ranks {
+		if rank == card.Rank {
+			return card.Suit.IsValid()
+		}
+	}
+	return false
+}
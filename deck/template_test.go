@@ -0,0 +1,107 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateSizes(t *testing.T) {
+	sizes := map[string]int{
+		StandardDeck: 52,
+		PiquetDeck:   32,
+		EuchreDeck:   24,
+		PinochleDeck: 48,
+		SpanishDeck:  40,
+		ItalianDeck:  40,
+		ShortDeck:    36,
+	}
+	assert.Equal(t, len(sizes), len(TemplateNames()))
+	for name, size := range sizes {
+		template, error := Template(name)
+		assert.Nil(t, error)
+		assert.Equal(t, size, len(template.Cards(0)), name)
+	}
+}
+
+func TestTemplateLookup(t *testing.T) {
+	template, error := Template("")
+	assert.Nil(t, error)
+	assert.Equal(t, StandardDeck, template.Name)
+
+	template, error = Template(" Euchre ")
+	assert.Nil(t, error)
+	assert.Equal(t, EuchreDeck, template.Name)
+
+	_, error = Template("tarot")
+	assert.NotNil(t, error)
+}
+
+func TestPinochleHasEveryCardTwice(t *testing.T) {
+	template, _ := Template(PinochleDeck)
+	counts := map[Card]int{}
+	for _, card := range template.Cards(0) {
+		counts[card]++
+	}
+	assert.Equal(t, 24, len(counts))
+	for card, count := range counts {
+		assert.Equal(t, 2, count, card.Code())
+		assert.True(t, card.Rank == Ace || card.Rank >= Nine)
+	}
+}
+
+func TestTemplateContains(t *testing.T) {
+	piquet, _ := Template(PiquetDeck)
+	assert.True(t, piquet.Contains(Card{Rank: Seven, Suit: Hearts}))
+	assert.True(t, piquet.Contains(Card{Rank: Ace, Suit: Spades}))
+	assert.False(t, piquet.Contains(Card{Rank: Six, Suit: Hearts}))
+	assert.False(t, piquet.Contains(Card{Rank: Joker}))
+
+	standard, _ := Template(StandardDeck)
+	assert.True(t, standard.Contains(Card{Rank: Joker}))
+	assert.False(t, standard.Contains(Card{Rank: Ace}))
+}
+
+func TestCreateDeckFromTemplates(t *testing.T) {
+	s := NewService(NewMemoryStore())
+
+	d, error := s.CreateDeck(DeckOptions{Type: StandardDeck, Jokers: 2})
+	assert.Nil(t, error)
+	assert.Equal(t, 54, len(d.Cards))
+	assert.True(t, d.Cards[53].IsJoker())
+	assert.Equal(t, StandardDeck, d.Type)
+
+	d, error = s.CreateDeck(DeckOptions{Type: ShortDeck, Shuffle: true})
+	assert.Nil(t, error)
+	assert.Equal(t, 36, len(d.Cards))
+	assert.Equal(t, ShortDeck, d.Type)
+
+	// codes are validated against the template
+	d, error = s.CreateDeck(DeckOptions{Type: EuchreDeck, Codes: "9S,AH,X"})
+	assert.NotNil(t, error)
+	d, error = s.CreateDeck(DeckOptions{Type: EuchreDeck, Codes: "9S,AH,2C"})
+	assert.NotNil(t, error)
+	d, error = s.CreateDeck(DeckOptions{Type: EuchreDeck, Codes: "9S,AH,TC"})
+	assert.Nil(t, error)
+	assert.Equal(t, 3, len(d.Cards))
+	d, error = s.CreateDeck(DeckOptions{Codes: "AS,X,x"})
+	assert.Nil(t, error)
+	assert.Equal(t, 3, len(d.Cards))
+
+	// jokers only for templates which allow them
+	_, error = s.CreateDeck(DeckOptions{Type: PiquetDeck, Jokers: 1})
+	assert.NotNil(t, error)
+	_, error = s.CreateDeck(DeckOptions{Jokers: -1})
+	assert.NotNil(t, error)
+
+	// jokers are limited, so that a request cannot allocate billions of cards
+	d, error = s.CreateDeck(DeckOptions{Jokers: MaxJokers})
+	assert.Nil(t, error)
+	assert.Equal(t, 52+MaxJokers, len(d.Cards))
+	_, error = s.CreateDeck(DeckOptions{Jokers: MaxJokers + 1})
+	assert.ErrorIs(t, error, ErrInvalidArgument)
+	_, error = s.CreateDeck(DeckOptions{Jokers: 2000000000})
+	assert.ErrorIs(t, error, ErrInvalidArgument)
+	_, error = s.CreateDeck(DeckOptions{Type: "bogus"})
+	assert.NotNil(t, error)
+}
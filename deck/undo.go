@@ -0,0 +1,165 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maximum number of operations of a deck which can be undone
+const MaxUndoDepth = 20
+
+/*
+Reverts the last operations on a deck. The undo is recorded as an event of
+its own and the reverted operations stay in the history marked as reverted,
+so that Redo can apply them again until the deck is changed by any other
+operation. Peeks and snapshots are not operations. Operations up to the
+creation, shuffle, clone, snapshot restore or close of a deck cannot be undone,
+so that a closed deck whose server seed may be revealed is never reopened
+inputs:
+	deckId  :  a UUID in string format
+	steps   :  number of operations to undo
+	version :  version of the deck the caller last saw
+returns:
+	the deck as it was before the undone operations
+	error if deck not found, steps is invalid, an operation older than the last
+	MaxUndoDepth operations would be undone, or ErrVersionConflict if the deck was changed since version
+*/
+func (s *Service) Undo(deckId string, steps int, version uint64) (Deck, error) {
+	if steps <= 0 {
+		return Deck{}, newError(ErrInvalidArgument, "steps must be more than zero")
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		if e := checkVersion(*deck, version); e != nil {
+			return e
+		}
+		undoable := deck.undoable()
+		if steps > len(undoable) {
+			message := fmt.Sprintf("cannot undo %d operations, deck has only %d", steps, len(undoable))
+			return newError(ErrNothingToUndo, message)
+		}
+		reverted := undoable[len(undoable)-steps:]
+		if deck.operationsSince(reverted[0]) > MaxUndoDepth {
+			message := fmt.Sprintf("cannot undo operations older than the last %d", MaxUndoDepth)
+			return newError(ErrInvalidArgument, message)
+		}
+		for _, i := range reverted {
+			deck.History[i].Reverted = true
+		}
+		deck.record(Event{Type: EventUndo, Events: reverted})
+		return deck.replayHistory()
+	})
+}
+
+/*
+Applies operations reverted by Undo again, oldest first. The redo is recorded
+as an event of its own and the operations are no longer marked as reverted
+inputs:
+	deckId  :  a UUID in string format
+	steps   :  number of operations to redo
+	version :  version of the deck the caller last saw
+returns:
+	the deck with the operations applied again
+	error if deck not found, fewer operations were undone, or
+	ErrVersionConflict if the deck was changed since version
+*/
+func (s *Service) Redo(deckId string, steps int, version uint64) (Deck, error) {
+	if steps <= 0 {
+		return Deck{}, newError(ErrInvalidArgument, "steps must be more than zero")
+	}
+	return s.mutate(deckId, func(deck *Deck) error {
+		if e := checkVersion(*deck, version); e != nil {
+			return e
+		}
+		redoable := deck.redoable()
+		if steps > len(redoable) {
+			message := fmt.Sprintf("cannot redo %d operations, only %d were undone", steps, len(redoable))
+			return newError(ErrNothingToUndo, message)
+		}
+		redone := redoable[:steps]
+		for _, i := range redone {
+			deck.History[i].Reverted = false
+		}
+		deck.record(Event{Type: EventRedo, Events: redone})
+		return deck.replayHistory()
+	})
+}
+
+// returns ErrVersionConflict if the deck has another version
+func checkVersion(d Deck, version uint64) error {
+	if version != d.Version {
+		return fmt.Errorf("deck is at version %d, not %d: %w", d.Version, version, ErrVersionConflict)
+	}
+	return nil
+}
+
+// returns the number of events up to the last creation, shuffle, clone, restore or close of the deck
+func (d Deck) undoBoundary() int {
+	for i := len(d.History) - 1; i >= 0; i-- {
+		switch d.History[i].Type {
+		case EventCreate, EventShuffle, EventClone, EventRestore, EventClose:
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// returns the history indices of the operations which can be undone, oldest first
+func (d Deck) undoable() []int {
+	undoable := []int{}
+	for i := d.undoBoundary(); i < len(d.History); i++ {
+		if d.History[i].isOperation() && !d.History[i].Reverted {
+			undoable = append(undoable, i)
+		}
+	}
+	return undoable
+}
+
+/*
+Returns the history indices of the operations which can be redone, oldest
+first, i.e. the operations reverted by the undos since the last operation
+which are not applied again by a redo yet
+*/
+func (d Deck) redoable() []int {
+	start := len(d.History)
+	for start > 0 && (d.History[start-1].Type == EventUndo || d.History[start-1].Type == EventRedo) {
+		start--
+	}
+	reverted := map[int]bool{}
+	for _, event := range d.History[start:] {
+		if event.Type != EventUndo {
+			continue
+		}
+		for _, i := range event.Events {
+			reverted[i] = d.History[i].Reverted
+		}
+	}
+	redoable := []int{}
+	for i, isReverted := range reverted {
+		if isReverted {
+			redoable = append(redoable, i)
+		}
+	}
+	sort.Ints(redoable)
+	return redoable
+}
+
+// returns the number of operations from the event at index on, including reverted ones
+func (d Deck) operationsSince(index int) int {
+	count := 0
+	for _, event := range d.History[index:] {
+		if event.isOperation() {
+			count++
+		}
+	}
+	return count
+}
+
+// rebuilds the cards, drawn cards and piles of the deck from its history, see Replay
+func (d *Deck) replayHistory() error {
+	replayed, e := Replay(*d, len(d.History)-1)
+	if e != nil {
+		return e
+	}
+	*d = replayed
+	return nil
+}
@@ -0,0 +1,144 @@
+package deck
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndoRedo(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "AS,KD,AC,2C,KH,10H")
+	deck_id := d.DeckId.String()
+
+	service.Draw(deck_id, DrawOptions{Count: 2, From: FromRandom})
+	afterDraw, _ := service.OpenDeck(deck_id)
+	service.AddToPile(deck_id, "discard", afterDraw.Drawn[0].Code())
+	service.ReshuffleRemaining(deck_id, "", 0)
+	latest, _ := service.OpenDeck(deck_id)
+
+	deck, error := service.Undo(deck_id, 2, latest.Version)
+	assert.Nil(t, error)
+	assert.Equal(t, afterDraw.Cards, deck.Cards)
+	assert.Equal(t, afterDraw.Drawn, deck.Drawn)
+	assert.Equal(t, 0, len(deck.Piles))
+	// the undo is recorded and the reverted operations are kept
+	undone := []int{len(afterDraw.History), len(afterDraw.History) + 1}
+	assert.Equal(t, len(latest.History)+1, len(deck.History))
+	assert.Equal(t, afterDraw.History, deck.History[:len(afterDraw.History)])
+	assert.Equal(t, Event{Type: EventUndo, Events: undone}, withoutTimestamp(deck.History[len(deck.History)-1]))
+	for _, i := range undone {
+		assert.True(t, deck.History[i].Reverted)
+	}
+
+	deck, error = service.Redo(deck_id, 1, deck.Version)
+	assert.Nil(t, error)
+	assert.Equal(t, 1, len(deck.Piles["discard"]))
+	deck, error = service.Redo(deck_id, 1, deck.Version)
+	assert.Nil(t, error)
+	assert.Equal(t, latest.Cards, deck.Cards)
+	assert.Equal(t, latest.History, deck.History[:len(latest.History)])
+	assert.Equal(t, EventRedo, deck.History[len(deck.History)-1].Type)
+	assert.Equal(t, []int{undone[1]}, deck.History[len(deck.History)-1].Events)
+
+	// the history replays to the same states
+	replayed, error := Replay(deck, len(latest.History))
+	assert.Nil(t, error)
+	assert.Equal(t, afterDraw.Cards, replayed.Cards)
+	assert.True(t, replayed.History[undone[0]].Reverted)
+	replayed, error = Replay(deck, len(deck.History)-1)
+	assert.Nil(t, error)
+	assert.Equal(t, deck, replayed)
+
+	_, error = service.Redo(deck_id, 1, deck.Version)
+	assert.NotNil(t, error)
+
+	// a new operation discards undone operations
+	service.Undo(deck_id, 1, deck.Version)
+	deck, _ = service.Cut(deck_id, 1)
+	_, error = service.Redo(deck_id, 1, deck.Version)
+	assert.NotNil(t, error)
+}
+
+func TestUndoLimits(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "")
+	deck_id := d.DeckId.String()
+
+	_, error := service.Undo(deck_id, 1, d.Version)
+	assert.NotNil(t, error, "creation of a deck cannot be undone")
+	_, error = service.Undo(deck_id, 0, d.Version)
+	assert.NotNil(t, error)
+
+	for i := 0; i < MaxUndoDepth+1; i++ {
+		service.DrawCards(deck_id, 1)
+	}
+	_, error = service.Undo(deck_id, MaxUndoDepth+1, currentVersion(deck_id))
+	assert.NotNil(t, error)
+	deck, error := service.Undo(deck_id, MaxUndoDepth, currentVersion(deck_id))
+	assert.Nil(t, error)
+	assert.Equal(t, 51, len(deck.Cards))
+	_, error = service.Undo(deck_id, 1, deck.Version)
+	assert.NotNil(t, error)
+
+	// the limit counts the reverted operations too
+	service.DrawCards(deck_id, 1)
+	_, error = service.Undo(deck_id, 2, currentVersion(deck_id))
+	assert.True(t, errors.Is(error, ErrInvalidArgument))
+	deck, error = service.Undo(deck_id, 1, currentVersion(deck_id))
+	assert.Nil(t, error)
+	assert.Equal(t, 51, len(deck.Cards))
+}
+
+func TestUndoAfterReveal(t *testing.T) {
+	d, _ := service.CreateNewDeck(true, "")
+	deck_id := d.DeckId.String()
+	service.DrawCards(deck_id, 1)
+	service.CloseDeck(deck_id)
+	_, error := service.RevealSeed(deck_id)
+	assert.Nil(t, error)
+
+	// the revealed seed tells the order of the remaining cards, the deck stays closed
+	_, error = service.Undo(deck_id, 1, currentVersion(deck_id))
+	assert.True(t, errors.Is(error, ErrNothingToUndo))
+	deck, _ := service.OpenDeck(deck_id)
+	assert.True(t, deck.Closed)
+	_, error = service.DrawCards(deck_id, 1)
+	assert.True(t, errors.Is(error, ErrDeckClosed))
+}
+
+func TestUndoVersionConflict(t *testing.T) {
+	d, _ := service.CreateNewDeck(false, "")
+	deck_id := d.DeckId.String()
+	_, deck, _ := service.Draw(deck_id, DrawOptions{Count: 1})
+	seen := deck.Version
+
+	// another client draws in between
+	service.DrawCards(deck_id, 1)
+	_, error := service.Undo(deck_id, 1, seen)
+	assert.True(t, errors.Is(error, ErrVersionConflict))
+
+	deck, _ = service.OpenDeck(deck_id)
+	seen = deck.Version
+	deck, error = service.Undo(deck_id, 1, seen)
+	assert.Nil(t, error)
+	assert.Equal(t, 51, len(deck.Cards))
+
+	_, error = service.Redo(deck_id, 1, seen)
+	assert.True(t, errors.Is(error, ErrVersionConflict))
+	deck, error = service.Redo(deck_id, 1, deck.Version)
+	assert.Nil(t, error)
+	assert.Equal(t, 50, len(deck.Cards))
+}
+
+// returns the event without its timestamp
+func withoutTimestamp(event Event) Event {
+	event.Timestamp = time.Time{}
+	return event
+}
+
+// returns the version of the stored deck
+func currentVersion(deckId string) uint64 {
+	deck, _ := service.OpenDeck(deckId)
+	return deck.Version
+}
@@ -1,8 +1,20 @@
 package main
 
-import "github.com/ketanbodas/manage-card-deck/api"
+import (
+	"flag"
+	"log"
+
+	"github.com/ketanbodas/manage-card-deck/api"
+)
 
 func main() {
+	config := api.DefaultConfig
+	flag.StringVar(&config.Addr, "addr", config.Addr, "address on which server listens")
+	flag.StringVar(&config.DataDir, "data-dir", config.DataDir, "directory in which decks are persisted, decks are kept in memory if not set")
+	flag.StringVar(&config.SQLitePath, "sqlite", config.SQLitePath, "path of SQLite database in which decks are persisted, takes precedence over -data-dir")
+	flag.BoolVar(&config.CorrectSuitNames, "correct-suit-names", config.CorrectSuitNames, "spell diamonds as DIAMONDS instead of the legacy DIMONDS")
+	flag.Parse()
+
 	// starts the server
-	api.StartServer()
+	log.Fatal(api.StartServerWithConfig(config))
 }